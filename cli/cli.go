@@ -0,0 +1,345 @@
+// Package cli provides the godriver command line, which runs the scenarios of a repository.
+//
+// A team embeds it by registering its scenarios and handing over the arguments:
+//
+//	func main() {
+//		repo := exec.NewRepository()
+//		repo.MustAdd(exec.NewTestScenario("login", loginExec, loginContexts), "smoke", 1)
+//		os.Exit(cli.Main(repo, os.Args[1:]))
+//	}
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/smancke/godriver/exec"
+)
+
+// The exit codes of the command line.
+const (
+	// ExitOK is returned, if all executions succeeded and all thresholds passed.
+	ExitOK = 0
+	// ExitErrors is returned, if at least one execution failed, a scenario was skipped or the run was aborted.
+	ExitErrors = 1
+	// ExitThresholds is returned, if at least one threshold failed.
+	// It takes precedence over ExitErrors.
+	ExitThresholds = 2
+	// ExitUsage is returned for invalid arguments or reports, which could not be set up.
+	ExitUsage = 3
+	// ExitReportFailed is returned, if a report could not be written.
+	ExitReportFailed = 4
+)
+
+// Output formats of the run command.
+const (
+	OutputConsole = "console"
+	OutputJSONL   = "jsonl"
+	OutputCSV     = "csv"
+	OutputQuiet   = "quiet"
+)
+
+const usage = `usage: godriver <command> [flags]
+
+commands:
+  list    show the scenarios, which a run selects, with their groups and tags
+  run     run the scenarios
+
+Run 'godriver <command> -h' for the flags of a command.
+`
+
+// Main runs the command line with the supplied arguments, without the program name,
+// on os.Stdout and os.Stderr and returns the exit code.
+// An interrupt aborts the run, so that the teardown hooks of the scenarios still run.
+func Main(repo *exec.Repository, args []string) int {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-interrupts:
+				fmt.Fprintln(os.Stderr, "aborting the run")
+				repo.Abort()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return Run(repo, args, os.Stdout, os.Stderr)
+}
+
+// Run runs the command line with the supplied arguments and returns the exit code.
+func Run(repo *exec.Repository, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ExitUsage
+	}
+	switch args[0] {
+	case "list":
+		return list(repo, args[1:], stdout, stderr)
+	case "run":
+		return run(repo, args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return ExitOK
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n%v", args[0], usage)
+	return ExitUsage
+}
+
+// filter holds the scenario selection flags, which are shared by all commands.
+type filter struct {
+	group           string
+	name            string
+	tags            stringList
+	groupExpression string
+	tagExpression   string
+}
+
+func (f *filter) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.group, "group", "", "regular expression for the test groups")
+	flags.StringVar(&f.name, "name", "", "regular expression for the scenario names")
+	flags.Var(&f.tags, "tag", "tag pattern, which the scenarios must have (repeatable)")
+	flags.StringVar(&f.groupExpression, "group-expr", "", "expression for the test groups, e.g. \"api || web\"")
+	flags.StringVar(&f.tagExpression, "tag-expr", "", "expression for the tags, e.g. \"smoke && !slow\"")
+}
+
+func (f *filter) exec() exec.Filter {
+	return exec.Filter{
+		Group:           f.group,
+		Name:            f.name,
+		Tags:            f.tags,
+		GroupExpression: f.groupExpression,
+		TagExpression:   f.tagExpression,
+	}
+}
+
+// stringList is a flag, which may be supplied multiple times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+func list(repo *exec.Repository, args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet("list", stderr)
+	f := &filter{}
+	f.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+
+	scenarios, err := repo.Select(f.exec())
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid filter: %v\n", err)
+		return ExitUsage
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tSCENARIO\tCONCURRENCY\tTAGS")
+	for _, s := range scenarios {
+		name := s.Name
+		if s.Dependency {
+			name += " (dependency)"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", s.TestGroup, name, s.Concurrency, strings.Join(s.Tags, ","))
+	}
+	w.Flush()
+	return ExitOK
+}
+
+func run(repo *exec.Repository, args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet("run", stderr)
+	f := &filter{}
+	f.register(flags)
+	concurrency := flags.Int("concurrency", 0, "number of workers for each scenario, overrides the registered concurrency")
+	parallel := flags.Bool("parallel", false, "run the selected scenarios at the same time")
+	output := flags.String("output", OutputConsole, "output format: console, jsonl, csv or quiet")
+	junit := flags.String("junit", "", "path of a JUnit XML report")
+	html := flags.String("html", "", "path of a HTML report")
+	results := flags.String("results", "", "path of a result file, CSV for .csv, else JSON lines")
+	metrics := flags.String("metrics", "", "address for serving Prometheus metrics during the run, e.g. :9100")
+	profile := flags.String("profile", "", "env profile, e.g. staging, defaults to $"+exec.ProfileVariable)
+	envDir := flags.String("env-dir", ".", "directory of the env and profile files")
+	envValues := &stringList{}
+	flags.Var(envValues, "env", "env value as key=value, overrides the profile (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %v\n", strings.Join(flags.Args(), " "))
+		return ExitUsage
+	}
+
+	scenarios, err := repo.Select(f.exec())
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid filter: %v\n", err)
+		return ExitUsage
+	}
+	if len(scenarios) == 0 {
+		fmt.Fprintln(stderr, "no scenarios match the filter")
+		return ExitUsage
+	}
+
+	loaded, err := (&exec.EnvLoader{Dir: *envDir, Profile: *profile}).Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "error loading env: %v\n", err)
+		return ExitUsage
+	}
+	for _, value := range *envValues {
+		i := strings.Index(value, "=")
+		if i < 1 {
+			fmt.Fprintf(stderr, "invalid env value %q, expected key=value\n", value)
+			return ExitUsage
+		}
+		loaded[value[:i]] = value[i+1:]
+	}
+	// the loaded env overrides the env of the repository
+	env := repo.Env()
+	for k, v := range loaded {
+		env[k] = v
+	}
+
+	// the console reporters of the repository are replaced by the output format, the others are kept
+	reporters := []exec.Reporter{}
+	for _, reporter := range repo.Reporters() {
+		if _, isConsole := reporter.(*exec.ConsoleReporter); !isConsole {
+			reporters = append(reporters, reporter)
+		}
+	}
+	switch *output {
+	case OutputConsole:
+		reporters = append(reporters, exec.NewConsoleReporter(stdout))
+	case OutputJSONL:
+		reporters = append(reporters, exec.NewResultWriter(stdout, exec.FormatJSONLines))
+	case OutputCSV:
+		reporters = append(reporters, exec.NewResultWriter(stdout, exec.FormatCSV))
+	case OutputQuiet:
+	default:
+		fmt.Fprintf(stderr, "unknown output format %q\n", *output)
+		return ExitUsage
+	}
+
+	if *junit != "" {
+		reporters = append(reporters, exec.NewJUnitReporter(*junit))
+	}
+	if *html != "" {
+		reporters = append(reporters, exec.NewHTMLReporter(*html))
+	}
+	if *results != "" {
+		writer, err := exec.NewResultFileWriter(*results)
+		if err != nil {
+			fmt.Fprintf(stderr, "error creating result file: %v\n", err)
+			return ExitUsage
+		}
+		defer writer.Close()
+		reporters = append(reporters, writer)
+	}
+	if *metrics != "" {
+		prometheus := exec.NewPrometheusReporter()
+		server, err := prometheus.Serve(*metrics)
+		if err != nil {
+			fmt.Fprintf(stderr, "error serving metrics: %v\n", err)
+			return ExitUsage
+		}
+		defer server.Close()
+		reporters = append(reporters, prometheus)
+	}
+
+	failures := &reportFailures{}
+	for i, reporter := range reporters {
+		if finisher, ok := reporter.(exec.RunFinisher); ok {
+			reporters[i] = &finishRecorder{Reporter: reporter, finisher: finisher, failures: failures}
+		}
+	}
+	defer repo.SetReporters(repo.Reporters()...)
+	defer repo.SetEnv(repo.Env())
+	repo.SetReporters(reporters...)
+	repo.SetEnv(env)
+	if *concurrency > 0 {
+		repo.OverrideConcurrency(*concurrency)
+	}
+	if *parallel {
+		repo.SetConcurrentScenarios(true)
+	}
+	report, err := repo.RunFiltered(f.exec())
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid filter: %v\n", err)
+		return ExitUsage
+	}
+	return exitCode(report, failures.failed)
+}
+
+func parseExitCode(err error) int {
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	return ExitUsage
+}
+
+// exitCode returns the exit code for the status of the run and the failures of the reporters.
+func exitCode(report *exec.RunReport, reportFailed bool) int {
+	switch report.Status() {
+	case exec.RunThresholdsFailed:
+		return ExitThresholds
+	case exec.RunFailed, exec.RunAborted:
+		return ExitErrors
+	}
+	if reportFailed {
+		return ExitReportFailed
+	}
+	return ExitOK
+}
+
+// reportFailures records, whether a reporter could not write its report.
+type reportFailures struct {
+	failed bool
+}
+
+// finishRecorder records the RunFinished errors of a reporter in the failures.
+// The repository calls RunFinished of all reporters one after another.
+type finishRecorder struct {
+	exec.Reporter
+	finisher exec.RunFinisher
+	failures *reportFailures
+}
+
+func (recorder *finishRecorder) ScenarioSkipped(run *exec.ScenarioRun) {
+	if skipper, ok := recorder.Reporter.(exec.SkipReporter); ok {
+		skipper.ScenarioSkipped(run)
+	}
+}
+
+func (recorder *finishRecorder) RunSummary(summary *exec.ScenarioRun) {
+	if summarizer, ok := recorder.Reporter.(exec.SummaryReporter); ok {
+		summarizer.RunSummary(summary)
+	}
+}
+
+func (recorder *finishRecorder) RunFinished() error {
+	err := recorder.finisher.RunFinished()
+	if err != nil {
+		recorder.failures.failed = true
+	}
+	return err
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/smancke/godriver/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func contexts(n int) func() chan exec.Context {
+	return func() chan exec.Context {
+		c := make(chan exec.Context, n)
+		for i := 0; i < n; i++ {
+			c <- exec.NewDefaultContext()
+		}
+		close(c)
+		return c
+	}
+}
+
+func newTestRepository(calls *int32) *exec.Repository {
+	repo := exec.NewRepository()
+	repo.MustAdd(exec.NewTestScenario("ok", exec.F("ok", func() error {
+		atomic.AddInt32(calls, 1)
+		return nil
+	}), contexts(3)), "smoke", 1, "fast")
+	repo.MustAdd(exec.NewTestScenario("failing", exec.F("failing", func() error {
+		return errors.New("failed")
+	}), contexts(1)), "regression", 2)
+	return repo
+}
+
+func Test_Cli_List(t *testing.T) {
+	a := assert.New(t)
+	var calls int32
+	stdout := bytes.NewBuffer(nil)
+
+	code := Run(newTestRepository(&calls), []string{"list", "-tag", "fast"}, stdout, ioutil.Discard)
+
+	a.Equal(ExitOK, code)
+	a.Equal("GROUP  SCENARIO  CONCURRENCY  TAGS\nsmoke  ok        1            fast\n", stdout.String())
+	a.Equal(int32(0), calls)
+}
+
+func Test_Cli_ListExpressions(t *testing.T) {
+	a := assert.New(t)
+	var calls int32
+	repo := newTestRepository(&calls)
+	repo.MustAdd(exec.NewTestScenario("checkout", exec.F("checkout", func() error { return nil }), contexts(1)).
+		DependsOn("ok"), "shop", 1, "slow")
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+
+	code := Run(repo, []string{"list", "-tag-expr", "slow && !fast", "-group-expr", "shop || regression"}, stdout, ioutil.Discard)
+
+	a.Equal(ExitOK, code)
+	a.Equal("GROUP  SCENARIO         CONCURRENCY  TAGS\n"+
+		"smoke  ok (dependency)  1            fast\n"+
+		"shop   checkout         1            slow\n", stdout.String())
+
+	a.Equal(ExitUsage, Run(repo, []string{"list", "-tag-expr", "slow &&"}, ioutil.Discard, stderr))
+	a.Equal(ExitUsage, Run(repo, []string{"run", "-group-expr", "(shop", "-output", "quiet"}, ioutil.Discard, stderr))
+	a.Contains(stderr.String(), `invalid filter: group expression: invalid expression "(shop": missing )`)
+	a.Equal(int32(0), calls)
+}
+
+func Test_Cli_RunExitCodes(t *testing.T) {
+	a := assert.New(t)
+	var calls int32
+
+	a.Equal(ExitOK, Run(newTestRepository(&calls), []string{"run", "-group", "smoke", "-output", "quiet"}, ioutil.Discard, ioutil.Discard))
+	a.Equal(int32(3), calls)
+
+	a.Equal(ExitErrors, Run(newTestRepository(&calls), []string{"run", "-output", "quiet"}, ioutil.Discard, ioutil.Discard))
+
+	repo := newTestRepository(&calls)
+	repo.MustAdd(exec.NewTestScenario("slow", exec.F("slow", func() error { return nil }), contexts(1)).
+		WithThresholds(exec.MustThreshold("count > 5")), "thresholds", 1)
+	a.Equal(ExitThresholds, Run(repo, []string{"run", "-name", "slow", "-output", "quiet"}, ioutil.Discard, ioutil.Discard))
+
+	repo = newTestRepository(&calls)
+	repo.MustAdd(exec.NewTestScenario("skipped", exec.F("skipped", func() error { return nil }), contexts(1)).
+		DependsOn("missing"), "skipped", 1)
+	a.Equal(ExitErrors, Run(repo, []string{"run", "-name", "skipped", "-output", "quiet"}, ioutil.Discard, ioutil.Discard))
+}
+
+func Test_Cli_Usage(t *testing.T) {
+	a := assert.New(t)
+	var calls int32
+	stderr := bytes.NewBuffer(nil)
+
+	a.Equal(ExitUsage, Run(newTestRepository(&calls), []string{}, ioutil.Discard, stderr))
+	a.Equal(ExitUsage, Run(newTestRepository(&calls), []string{"foo"}, ioutil.Discard, stderr))
+	a.Equal(ExitUsage, Run(newTestRepository(&calls), []string{"run", "-output", "xml"}, ioutil.Discard, stderr))
+	a.Equal(ExitUsage, Run(newTestRepository(&calls), []string{"run", "-group", "none"}, ioutil.Discard, stderr))
+	a.Equal(ExitUsage, Run(newTestRepository(&calls), []string{"run", "-unknown"}, ioutil.Discard, stderr))
+	a.Contains(stderr.String(), "unknown command \"foo\"")
+	a.Equal(int32(0), calls)
+}
+
+func Test_Cli_RunParallel(t *testing.T) {
+	a := assert.New(t)
+	var calls int32
+	stdout := bytes.NewBuffer(nil)
+
+	code := Run(newTestRepository(&calls), []string{"run", "-parallel"}, stdout, ioutil.Discard)
+
+	a.Equal(ExitErrors, code)
+	a.Equal(int32(3), calls)
+	a.Contains(stdout.String(), "started smoke/ok")
+	a.Contains(stdout.String(), "started regression/failing")
+	a.Regexp(`summary/all scenarios  workers 0/3 .*\n.*\ntotal\s+4\s`, stdout.String())
+}
+
+func Test_Cli_RunOutputAndReports(t *testing.T) {
+	a := assert.New(t)
+	var calls int32
+	dir, err := ioutil.TempDir("", "godriver-cli")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	stdout := bytes.NewBuffer(nil)
+
+	code := Run(newTestRepository(&calls), []string{"run",
+		"-group", "smoke",
+		"-concurrency", "2",
+		"-output", "jsonl",
+		"-junit", filepath.Join(dir, "junit.xml"),
+		"-results", filepath.Join(dir, "results.csv"),
+	}, stdout, ioutil.Discard)
+
+	a.Equal(ExitOK, code)
+	a.Equal(3, strings.Count(stdout.String(), "\n"))
+	executions, err := exec.LoadExecutionsFile(filepath.Join(dir, "results.csv"))
+	a.NoError(err)
+	a.Equal(3, len(executions))
+	junit, err := ioutil.ReadFile(filepath.Join(dir, "junit.xml"))
+	a.NoError(err)
+	a.Contains(string(junit), "<testsuite")
+}
+
+func Test_Cli_ReportFailed(t *testing.T) {
+	a := assert.New(t)
+	var calls int32
+
+	code := Run(newTestRepository(&calls), []string{"run", "-group", "smoke", "-output", "quiet",
+		"-junit", filepath.Join("does", "not", "exist", "junit.xml")}, ioutil.Discard, ioutil.Discard)
+
+	a.Equal(ExitReportFailed, code)
+}
+
+func Test_Cli_RunEnv(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "godriver-cli-env")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	a.NoError(ioutil.WriteFile(filepath.Join(dir, "env.staging.yaml"), []byte("host: staging\nport: 443\n"), 0644))
+
+	var env map[string]string
+	repo := exec.NewRepository()
+	repo.MustAdd(exec.NewTestScenario("env", envExec(func(cntx exec.Context) {
+		env = cntx.Env()
+	}), contexts(1)), "env", 1)
+
+	code := Run(repo, []string{"run", "-output", "quiet", "-env-dir", dir, "-profile", "staging", "-env", "port=8443"}, ioutil.Discard, ioutil.Discard)
+
+	a.Equal(ExitOK, code)
+	a.Equal("staging", env["host"])
+	a.Equal("8443", env["port"])
+	a.Equal("staging", env["profile"])
+
+	a.Equal(ExitUsage, Run(repo, []string{"run", "-env-dir", dir, "-profile", "prod"}, ioutil.Discard, ioutil.Discard))
+	a.Equal(ExitUsage, Run(repo, []string{"run", "-env-dir", dir, "-env", "novalue"}, ioutil.Discard, ioutil.Discard))
+}
+
+func Test_Cli_RunKeepsRepositoryConfiguration(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "godriver-cli-env")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	junit := bytes.NewBuffer(nil)
+	var env map[string]string
+	repo := exec.NewRepository()
+	repo.SetReporters(exec.NewConsoleReporter(ioutil.Discard), exec.NewJUnitWriterReporter(junit))
+	repo.SetEnv(map[string]string{"team": "payments", "host": "localhost"})
+	repo.MustAdd(exec.NewTestScenario("env", envExec(func(cntx exec.Context) {
+		env = cntx.Env()
+	}), contexts(1)), "env", 1)
+
+	code := Run(repo, []string{"run", "-output", "quiet", "-env-dir", dir, "-env", "host=staging"}, ioutil.Discard, ioutil.Discard)
+
+	a.Equal(ExitOK, code)
+	a.Equal("payments", env["team"])
+	a.Equal("staging", env["host"])
+	a.Contains(junit.String(), "<testsuite")
+	a.Equal(2, len(repo.Reporters()))
+	a.Equal(map[string]string{"team": "payments", "host": "localhost"}, repo.Env())
+}
+
+func Test_Cli_RunAborted(t *testing.T) {
+	a := assert.New(t)
+	repo := exec.NewRepository()
+	repo.MustAdd(exec.NewTestScenario("abort", exec.F("abort", func() error {
+		repo.Abort()
+		return nil
+	}), contexts(1)), "abort", 1)
+
+	a.Equal(ExitErrors, Run(repo, []string{"run", "-output", "quiet"}, ioutil.Discard, ioutil.Discard))
+}
+
+// envExec is a step, which hands the context to the function.
+type envExec func(cntx exec.Context)
+
+func (f envExec) Exec(cntx exec.Context) error {
+	f(cntx)
+	return nil
+}
+
+func (f envExec) String(cntx exec.Context) string {
+	return "envExec"
+}
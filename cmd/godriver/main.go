@@ -0,0 +1,15 @@
+// Command godriver is the command line of godriver with an empty repository.
+// To run own scenarios, register them in a main package of the same shape
+// and hand over to cli.Main, see package github.com/smancke/godriver/cli.
+package main
+
+import (
+	"os"
+
+	"github.com/smancke/godriver/cli"
+	"github.com/smancke/godriver/exec"
+)
+
+func main() {
+	os.Exit(cli.Main(exec.NewRepository(), os.Args[1:]))
+}
@@ -0,0 +1,272 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Tolerance defines the allowed regression of a run compared to its baseline.
+// The zero value allows no regression at all.
+type Tolerance struct {
+	// LatencyIncrease is the allowed relative increase of the latency percentiles, e.g. 0.1 for 10%.
+	LatencyIncrease float64
+	// ThroughputDecrease is the allowed relative decrease of the throughput, e.g. 0.1 for 10%.
+	ThroughputDecrease float64
+	// ErrorRateIncrease is the allowed absolute increase of the error rate, e.g. 0.01 for one percent point.
+	ErrorRateIncrease float64
+	// Percentiles are the compared latency percentiles. It defaults to 50, 95 and 99.
+	Percentiles []float64
+	// AllowMissing accepts scenarios and steps of the baseline, which are missing in the current run.
+	// Otherwise, they are regressions.
+	AllowMissing bool
+}
+
+// Comparison is the result of comparing a run against a baseline.
+type Comparison struct {
+	Steps []*StepComparison
+}
+
+// StepComparison compares the metrics of a step, or the total of a scenario.
+type StepComparison struct {
+	TestGroup string
+	Scenario  string
+	// Step is the name of the step, or empty for the scenario total.
+	Step string
+	// Missing is set, if the step is only contained in one of the runs.
+	// It is "baseline" or "current" for the run, which does not contain the step.
+	Missing string
+	Metrics []MetricComparison
+	// allowMissing is the AllowMissing of the tolerance.
+	allowMissing bool
+}
+
+// MetricComparison compares a single metric.
+type MetricComparison struct {
+	// Metric is a percentile like p95, MetricThroughput or MetricErrorRate.
+	Metric   string
+	Baseline float64
+	Current  float64
+	// Regression is true, if the change exceeds the tolerance.
+	Regression bool
+}
+
+type comparedScenario struct {
+	testGroup string
+	scenario  string
+	stats     *Stats
+}
+
+// Compare aggregates the baseline and current executions
+// and compares each scenario and step.
+func Compare(baseline, current []*Execution, tolerance Tolerance) *Comparison {
+	if tolerance.Percentiles == nil {
+		tolerance.Percentiles = []float64{50, 95, 99}
+	}
+	baselineScenarios := aggregateScenarios(baseline)
+	currentScenarios := aggregateScenarios(current)
+
+	comparison := &Comparison{}
+	for _, b := range baselineScenarios {
+		c := findScenario(currentScenarios, b.testGroup, b.scenario)
+		comparison.add(b, c, tolerance)
+	}
+	for _, c := range currentScenarios {
+		if findScenario(baselineScenarios, c.testGroup, c.scenario) == nil {
+			comparison.add(nil, c, tolerance)
+		}
+	}
+	return comparison
+}
+
+func aggregateScenarios(executions []*Execution) []*comparedScenario {
+	scenarios := []*comparedScenario{}
+	for _, group := range groupByScenario(executions) {
+		stats := NewStats()
+		for _, execution := range group {
+			stats.Add(execution)
+		}
+		scenarios = append(scenarios, &comparedScenario{group[0].testGroup, group[0].scenario, stats})
+	}
+	return scenarios
+}
+
+func findScenario(scenarios []*comparedScenario, testGroup, scenario string) *comparedScenario {
+	for _, s := range scenarios {
+		if s.testGroup == testGroup && s.scenario == scenario {
+			return s
+		}
+	}
+	return nil
+}
+
+func (comparison *Comparison) add(baseline, current *comparedScenario, tolerance Tolerance) {
+	reference := baseline
+	if reference == nil {
+		reference = current
+	}
+	names := []string{""}
+	seen := map[string]bool{}
+	for _, s := range []*comparedScenario{baseline, current} {
+		if s == nil {
+			continue
+		}
+		for _, step := range s.stats.Steps() {
+			if !seen[step.Name] {
+				seen[step.Name] = true
+				names = append(names, step.Name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		stepComparison := &StepComparison{
+			TestGroup:    reference.testGroup,
+			Scenario:     reference.scenario,
+			Step:         name,
+			allowMissing: tolerance.AllowMissing,
+		}
+		switch {
+		case !hasStep(baseline, name):
+			stepComparison.Missing = "baseline"
+		case !hasStep(current, name):
+			stepComparison.Missing = "current"
+		default:
+			stepComparison.Metrics = compareStep(baseline.stats, current.stats, name, tolerance)
+		}
+		comparison.Steps = append(comparison.Steps, stepComparison)
+	}
+}
+
+func hasStep(s *comparedScenario, name string) bool {
+	if s == nil {
+		return false
+	}
+	if name == "" {
+		return true
+	}
+	_, exists := s.stats.Step(name)
+	return exists
+}
+
+func compareStep(baseline, current *Stats, name string, tolerance Tolerance) []MetricComparison {
+	summary := func(stats *Stats) StatsSummary {
+		if name == "" {
+			return stats.Total()
+		}
+		s, _ := stats.Step(name)
+		return s
+	}
+	b, c := summary(baseline), summary(current)
+
+	metrics := []MetricComparison{}
+	for _, p := range tolerance.Percentiles {
+		bp := float64(baseline.Percentile(name, p))
+		cp := float64(current.Percentile(name, p))
+		metrics = append(metrics, MetricComparison{
+			Metric:     "p" + strconv.FormatFloat(p, 'f', -1, 64),
+			Baseline:   bp,
+			Current:    cp,
+			Regression: cp > bp*(1+tolerance.LatencyIncrease),
+		})
+	}
+	metrics = append(metrics, MetricComparison{
+		Metric:     MetricThroughput,
+		Baseline:   b.Throughput(),
+		Current:    c.Throughput(),
+		Regression: c.Throughput() < b.Throughput()*(1-tolerance.ThroughputDecrease),
+	})
+	metrics = append(metrics, MetricComparison{
+		Metric:     MetricErrorRate,
+		Baseline:   b.ErrorRate(),
+		Current:    c.ErrorRate(),
+		Regression: c.ErrorRate()-b.ErrorRate() > tolerance.ErrorRateIncrease,
+	})
+	return metrics
+}
+
+// Passed returns true, if no metric regressed beyond its tolerance.
+func (comparison *Comparison) Passed() bool {
+	return len(comparison.Regressions()) == 0
+}
+
+// Regressions returns the step comparisons, which contain a regression.
+func (comparison *Comparison) Regressions() []*StepComparison {
+	regressions := []*StepComparison{}
+	for _, step := range comparison.Steps {
+		if step.Regressed() {
+			regressions = append(regressions, step)
+		}
+	}
+	return regressions
+}
+
+// Regressed returns true, if one of the metrics regressed,
+// or if the step is missing in the current run and the tolerance does not allow it.
+func (step *StepComparison) Regressed() bool {
+	if step.Missing == "current" && !step.allowMissing {
+		return true
+	}
+	for _, metric := range step.Metrics {
+		if metric.Regression {
+			return true
+		}
+	}
+	return false
+}
+
+// Change returns the relative change from the baseline to the current value.
+func (metric MetricComparison) Change() float64 {
+	if metric.Baseline == 0 {
+		return 0
+	}
+	return (metric.Current - metric.Baseline) / metric.Baseline
+}
+
+func (metric MetricComparison) format(value float64) string {
+	switch metric.Metric {
+	case MetricThroughput:
+		return fmt.Sprintf("%.1f rps", value)
+	case MetricErrorRate:
+		return fmt.Sprintf("%.2f%%", value*100)
+	default:
+		return formatDuration(time.Duration(value))
+	}
+}
+
+func (metric MetricComparison) String() string {
+	status := "ok"
+	if metric.Regression {
+		status = "REGRESSION"
+	}
+	change := fmt.Sprintf("%+.1f%%", metric.Change()*100)
+	if metric.Metric == MetricErrorRate {
+		change = fmt.Sprintf("%+.2fpp", (metric.Current-metric.Baseline)*100)
+	}
+	return fmt.Sprintf("%-12v %12v -> %-12v %10v  %v", metric.Metric, metric.format(metric.Baseline), metric.format(metric.Current), change, status)
+}
+
+// String returns the comparison as readable table.
+func (comparison *Comparison) String() string {
+	b := bytes.NewBuffer(nil)
+	for _, step := range comparison.Steps {
+		name := "total"
+		if step.Step != "" {
+			name = step.Step
+		}
+		fmt.Fprintf(b, "%v/%v %v\n", step.TestGroup, step.Scenario, name)
+		if step.Missing != "" {
+			if step.Regressed() {
+				fmt.Fprintf(b, "  missing in %v  REGRESSION\n", step.Missing)
+			} else {
+				fmt.Fprintf(b, "  missing in %v\n", step.Missing)
+			}
+			continue
+		}
+		for _, metric := range step.Metrics {
+			fmt.Fprintf(b, "  %v\n", metric)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newCompareTestExecutions(scenario string, count int, latency time.Duration, errorEvery int) []*Execution {
+	start := time.Now()
+	executions := []*Execution{}
+	for i := 1; i <= count; i++ {
+		var err error
+		if errorEvery > 0 && i%errorEvery == 0 {
+			err = errors.New("failed")
+		}
+		execution := newTestExecution(scenario, start.Add(time.Duration(i)*10*time.Millisecond), latency, err,
+			newTestExecution("login", start, latency/2, nil))
+		execution.setScenario(scenario, "group")
+		executions = append(executions, execution)
+	}
+	return executions
+}
+
+func Test_Compare_Passed(t *testing.T) {
+	a := assert.New(t)
+
+	baseline := newCompareTestExecutions("spec", 100, 100*time.Millisecond, 0)
+	current := newCompareTestExecutions("spec", 100, 105*time.Millisecond, 0)
+
+	comparison := Compare(baseline, current, Tolerance{LatencyIncrease: 0.1, ThroughputDecrease: 0.1})
+	a.True(comparison.Passed())
+	a.Equal(2, len(comparison.Steps))
+	a.Equal("", comparison.Steps[0].Step)
+	a.Equal("login", comparison.Steps[1].Step)
+
+	p95 := comparison.Steps[0].Metrics[1]
+	a.Equal("p95", p95.Metric)
+	a.InDelta(0.05, p95.Change(), 0.0001)
+	a.Contains(comparison.String(), "group/spec total\n  p50                 100ms -> 105ms             +5.0%  ok\n")
+}
+
+func Test_Compare_Regressions(t *testing.T) {
+	a := assert.New(t)
+
+	baseline := append(newCompareTestExecutions("spec", 100, 100*time.Millisecond, 0),
+		newCompareTestExecutions("removed", 1, time.Millisecond, 0)...)
+	current := append(newCompareTestExecutions("spec", 100, 120*time.Millisecond, 10),
+		newCompareTestExecutions("added", 1, time.Millisecond, 0)...)
+
+	comparison := Compare(baseline, current, Tolerance{LatencyIncrease: 0.1, ThroughputDecrease: 0.1, ErrorRateIncrease: 0.05, Percentiles: []float64{99}})
+	a.False(comparison.Passed())
+
+	regressions := comparison.Regressions()
+	a.Equal(4, len(regressions))
+	total := regressions[0].Metrics
+	a.Equal([]string{"p99", MetricThroughput, MetricErrorRate}, []string{total[0].Metric, total[1].Metric, total[2].Metric})
+	a.True(total[0].Regression)
+	a.False(total[1].Regression)
+	a.True(total[2].Regression)
+	a.Equal("error_rate          0.00% -> 10.00%         +10.00pp  REGRESSION", total[2].String())
+
+	a.Equal("current", comparison.Steps[2].Missing)
+	a.Equal("removed", comparison.Steps[2].Scenario)
+	a.Equal("baseline", comparison.Steps[len(comparison.Steps)-1].Missing)
+	a.Contains(comparison.String(), "group/added total\n  missing in baseline\n")
+	a.Contains(comparison.String(), "group/removed total\n  missing in current  REGRESSION\n")
+}
+
+func Test_Compare_Missing(t *testing.T) {
+	a := assert.New(t)
+
+	baseline := append(newCompareTestExecutions("spec", 10, time.Millisecond, 0),
+		newCompareTestExecutions("removed", 10, time.Millisecond, 0)...)
+	current := newCompareTestExecutions("spec", 10, time.Millisecond, 0)
+
+	comparison := Compare(baseline, current, Tolerance{LatencyIncrease: 0.1})
+	a.False(comparison.Passed())
+	a.Equal(2, len(comparison.Regressions()))
+	a.Equal("removed", comparison.Regressions()[0].Scenario)
+	a.Equal("current", comparison.Regressions()[0].Missing)
+
+	comparison = Compare(baseline, current, Tolerance{LatencyIncrease: 0.1, AllowMissing: true})
+	a.True(comparison.Passed())
+	a.Contains(comparison.String(), "group/removed total\n  missing in current\n")
+
+	comparison = Compare(baseline[:10], append(current, newCompareTestExecutions("added", 1, time.Millisecond, 0)...), Tolerance{LatencyIncrease: 0.1})
+	a.True(comparison.Passed())
+}
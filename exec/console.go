@@ -0,0 +1,272 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConsoleReporter shows the progress of the scenario runs on the console.
+// On a terminal it refreshes a table with the stats of each running scenario and its steps.
+// Otherwise, it writes a progress line per running scenario in each interval
+// and the table at the end of the scenario.
+// Failed executions are always written as single lines.
+type ConsoleReporter struct {
+	out      io.Writer
+	live     bool
+	interval time.Duration
+
+	mutex      sync.Mutex
+	runs       []*consoleRun
+	stop       chan struct{}
+	stopped    chan struct{}
+	drawnLines int
+}
+
+// consoleRun is the progress of a running scenario.
+type consoleRun struct {
+	run         *ScenarioRun
+	lastRefresh time.Time
+	lastCounts  map[string]int
+	rates       map[string]float64
+}
+
+// NewConsoleReporter creates a reporter writing to out.
+// The live view is used, if out is a terminal.
+func NewConsoleReporter(out io.Writer) *ConsoleReporter {
+	reporter := &ConsoleReporter{
+		out:      out,
+		live:     isTerminal(out),
+		interval: 5 * time.Second,
+	}
+	if reporter.live {
+		reporter.interval = 500 * time.Millisecond
+	}
+	return reporter
+}
+
+// WithInterval sets the interval of the table refresh or progress lines.
+func (reporter *ConsoleReporter) WithInterval(interval time.Duration) *ConsoleReporter {
+	reporter.interval = interval
+	return reporter
+}
+
+// WithLiveView enables or disables the live view, independent of the terminal detection.
+func (reporter *ConsoleReporter) WithLiveView(live bool) *ConsoleReporter {
+	reporter.live = live
+	return reporter
+}
+
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (reporter *ConsoleReporter) ScenarioStarted(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.runs = append(reporter.runs, &consoleRun{
+		run:         run,
+		lastRefresh: run.Start,
+		lastCounts:  make(map[string]int),
+		rates:       make(map[string]float64),
+	})
+	if !reporter.live {
+		fmt.Fprintf(reporter.out, "started %v/%v with %v workers\n", run.TestGroup, run.Name, run.Concurrency)
+	}
+	if len(reporter.runs) == 1 {
+		reporter.stop = make(chan struct{})
+		reporter.stopped = make(chan struct{})
+		go reporter.refreshLoop(reporter.stop, reporter.stopped)
+	}
+}
+
+func (reporter *ConsoleReporter) Report(run *ScenarioRun, execution *Execution) {
+	if execution.err == nil {
+		return
+	}
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.clear()
+	fmt.Fprintln(reporter.out, execution.String())
+}
+
+func (reporter *ConsoleReporter) ScenarioFinished(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	var finished *consoleRun
+	for i, r := range reporter.runs {
+		if r.run == run {
+			finished = r
+			reporter.runs = append(reporter.runs[:i], reporter.runs[i+1:]...)
+			break
+		}
+	}
+	stop, stopped := reporter.stop, reporter.stopped
+	last := len(reporter.runs) == 0
+	reporter.mutex.Unlock()
+	if finished == nil {
+		return
+	}
+	if last {
+		close(stop)
+		<-stopped
+	}
+
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	// the final table shows the average throughput
+	finished.rates[""] = run.Stats.Total().Throughput()
+	for _, step := range run.Stats.Steps() {
+		finished.rates[step.Name] = step.Throughput()
+	}
+	reporter.clear()
+	reporter.out.Write(finished.table())
+	if run.AbortedBy != nil {
+		fmt.Fprintf(reporter.out, "aborted by threshold %v\n", run.AbortedBy)
+	}
+	if run.Verdict != nil {
+		io.WriteString(reporter.out, run.Verdict.String())
+	}
+}
+
+// RunSummary writes the table of the executions of all scenarios, which ran concurrently.
+func (reporter *ConsoleReporter) RunSummary(summary *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	r := &consoleRun{run: summary, rates: map[string]float64{"": summary.Stats.Total().Throughput()}}
+	for _, step := range summary.Stats.Steps() {
+		r.rates[step.Name] = step.Throughput()
+	}
+	reporter.clear()
+	reporter.out.Write(r.table())
+}
+
+func (reporter *ConsoleReporter) ScenarioSkipped(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	fmt.Fprintf(reporter.out, "skipped %v/%v: %v\n", run.TestGroup, run.Name, run.SkipReason)
+}
+
+func (reporter *ConsoleReporter) refreshLoop(stop, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(reporter.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reporter.refresh()
+		}
+	}
+}
+
+func (reporter *ConsoleReporter) refresh() {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	for _, r := range reporter.runs {
+		r.updateRates()
+	}
+	if !reporter.live {
+		for _, r := range reporter.runs {
+			total := r.run.Stats.Total()
+			fmt.Fprintf(reporter.out, "%v/%v: %v executions, %.1f rps, %.2f%% errors, p95 %v, %v workers, elapsed %v, remaining %v\n",
+				r.run.TestGroup, r.run.Name, total.Count, r.rates[""], total.ErrorRate()*100,
+				formatDuration(total.P95), r.run.ActiveWorkers(), formatDuration(r.run.Elapsed()), r.remaining())
+		}
+		return
+	}
+	reporter.clear()
+	table := bytes.NewBuffer(nil)
+	for _, r := range reporter.runs {
+		table.Write(r.table())
+	}
+	reporter.out.Write(table.Bytes())
+	reporter.drawnLines = bytes.Count(table.Bytes(), []byte("\n"))
+}
+
+// clear removes the live tables from the terminal.
+func (reporter *ConsoleReporter) clear() {
+	if reporter.live && reporter.drawnLines > 0 {
+		fmt.Fprintf(reporter.out, "\033[%dA\033[J", reporter.drawnLines)
+	}
+	reporter.drawnLines = 0
+}
+
+// updateRates calculates the current executions per second since the last refresh.
+func (r *consoleRun) updateRates() {
+	now := time.Now()
+	seconds := now.Sub(r.lastRefresh).Seconds()
+	if seconds <= 0 {
+		return
+	}
+	counts := map[string]int{"": r.run.Stats.Total().Count}
+	for _, step := range r.run.Stats.Steps() {
+		counts[step.Name] = step.Count
+	}
+	for name, count := range counts {
+		r.rates[name] = float64(count-r.lastCounts[name]) / seconds
+	}
+	r.lastCounts = counts
+	r.lastRefresh = now
+}
+
+func (r *consoleRun) remaining() string {
+	remaining, known := r.run.Remaining()
+	if !known {
+		return "-"
+	}
+	return formatDuration(remaining)
+}
+
+func (r *consoleRun) table() []byte {
+	run := r.run
+	b := bytes.NewBuffer(nil)
+	fmt.Fprintf(b, "%v/%v  workers %v/%v  elapsed %v  remaining %v\n",
+		run.TestGroup, run.Name, run.ActiveWorkers(), run.Concurrency, formatDuration(run.Elapsed()), r.remaining())
+	fmt.Fprintf(b, "%-40v %8v %8v %8v %10v %10v %10v %10v %10v\n", "STEP", "COUNT", "RPS", "ERR%", "P50", "P90", "P95", "P99", "MAX")
+	total := run.Stats.Total()
+	total.Name = "total"
+	tableRow(b, total, r.rates[""])
+	for _, step := range run.Stats.Steps() {
+		rate := r.rates[step.Name]
+		step.Name = "  " + step.Name
+		tableRow(b, step, rate)
+	}
+	return b.Bytes()
+}
+
+func tableRow(b *bytes.Buffer, summary StatsSummary, rate float64) {
+	fmt.Fprintf(b, "%-40v %8v %8.1f %7.2f%% %10v %10v %10v %10v %10v\n",
+		truncate(summary.Name, 40), summary.Count, rate, summary.ErrorRate()*100,
+		formatDuration(summary.P50), formatDuration(summary.P90), formatDuration(summary.P95),
+		formatDuration(summary.P99), formatDuration(summary.Max))
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length-3] + "..."
+}
@@ -0,0 +1,121 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ConsoleReporter_Lines(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	reporter := NewConsoleReporter(out)
+	a.False(reporter.live)
+
+	run := &ScenarioRun{Name: "scenario", TestGroup: "group", Concurrency: 2, Start: time.Now(), Stats: NewStats()}
+	reporter.ScenarioStarted(run)
+	for _, err := range []error{nil, errors.New("some error")} {
+		execution := newTestExecution("scenario", time.Now(), time.Millisecond, err,
+			newTestExecution("login", time.Now(), time.Millisecond, err))
+		run.Stats.Add(execution)
+		reporter.Report(run, execution)
+	}
+	run.finish()
+	reporter.ScenarioFinished(run)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	a.Equal(6, len(lines))
+	a.Equal("started group/scenario with 2 workers", lines[0])
+	a.Contains(lines[1], "scenario: some error")
+	a.Contains(lines[2], "group/scenario")
+	a.Contains(lines[3], "STEP")
+	a.Regexp(`^total\s+2\s+\S+\s+50.00%`, lines[4])
+	a.Regexp(`^  login\s+2\s+\S+\s+50.00%`, lines[5])
+}
+
+func Test_ConsoleReporter_Live(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	reporter := NewConsoleReporter(out).
+		WithLiveView(true).
+		WithInterval(time.Millisecond)
+
+	run := &ScenarioRun{Name: "scenario", TestGroup: "group", ExpectedExecutions: 2, Start: time.Now(), Stats: NewStats()}
+	reporter.ScenarioStarted(run)
+	run.Stats.Add(newTestExecution("scenario", time.Now(), time.Millisecond, nil))
+	time.Sleep(20 * time.Millisecond)
+	run.finish()
+	reporter.ScenarioFinished(run)
+
+	// the table was redrawn
+	a.Contains(out.String(), "\033[3A\033[J")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	a.Contains(lines[len(lines)-3], "remaining 0s")
+	a.Regexp(`^total\s+1\s`, lines[len(lines)-1])
+}
+
+func Test_FormatDuration(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("1.23s", formatDuration(1234567890))
+	a.Equal("12.35ms", formatDuration(12345678))
+	a.Equal("12µs", formatDuration(12345))
+}
+
+func Test_ConsoleReporter_ConcurrentRuns(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	reporter := NewConsoleReporter(out).WithInterval(time.Millisecond)
+
+	first := &ScenarioRun{Name: "first", TestGroup: "group", Concurrency: 1, Start: time.Now(), Stats: NewStats()}
+	second := &ScenarioRun{Name: "second", TestGroup: "group", Concurrency: 1, Start: time.Now(), Stats: NewStats()}
+	reporter.ScenarioStarted(first)
+	reporter.ScenarioStarted(second)
+	first.Stats.Add(newTestExecution("first", time.Now(), time.Millisecond, nil))
+	second.Stats.Add(newTestExecution("second", time.Now(), time.Millisecond, nil))
+	time.Sleep(20 * time.Millisecond)
+	first.finish()
+	reporter.ScenarioFinished(first)
+
+	time.Sleep(20 * time.Millisecond)
+	second.finish()
+	reporter.ScenarioFinished(second)
+
+	// after the final table of the first run, only the second one is refreshed
+	afterFirst := out.String()[strings.Index(out.String(), "group/first  workers"):]
+	a.Contains(afterFirst, "group/second: 1 executions")
+	a.NotContains(afterFirst, "group/first:")
+
+	summary := &ScenarioRun{Name: "all scenarios", TestGroup: "summary", Start: first.Start, Stats: NewStats()}
+	summary.Stats.Add(newTestExecution("first", time.Now(), time.Millisecond, nil))
+	summary.Stats.Add(newTestExecution("second", time.Now(), time.Millisecond, nil))
+	summary.finish()
+	out.Reset()
+	reporter.RunSummary(summary)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	a.Contains(lines[0], "summary/all scenarios")
+	a.Regexp(`^total\s+2\s`, lines[2])
+}
+
+func Test_ConsoleReporter_LiveRun(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	repo := NewRepository()
+	repo.SetReporters(NewConsoleReporter(out).WithInterval(time.Millisecond))
+	repo.MustAdd(NewTestScenario("live", F("slow", func() error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}), contextsFactory(20)).
+		WithBeforeAll(func(cntx Context) (map[string]string, error) {
+			time.Sleep(5 * time.Millisecond)
+			return nil, nil
+		}), "group", 2)
+
+	report, err := repo.RunTestScenarios("", "")
+	a.NoError(err)
+	a.Equal(RunPassed, report.Status())
+	a.Contains(out.String(), "group/live: ")
+	a.Contains(out.String(), " workers, elapsed ")
+}
@@ -3,7 +3,8 @@ package exec
 import (
 	"bytes"
 	"math/rand"
-	"text/template"
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -25,7 +26,8 @@ type Context interface {
 	// test groups.
 	Env() map[string]string
 
-	// ExpandVars executes the supplied go template with the context as data context
+	// ExpandVars executes the supplied go template with the context as data context.
+	// The functions of TemplateFuncs are available in the template.
 	ExpandVars(template string) (string, error)
 
 	// ExpandVarsNoError Same as expand vars, but returning template it self in case of an error
@@ -46,11 +48,94 @@ type Context interface {
 	CorrelationId() string
 }
 
+// The following interfaces are optional extensions of a Context, which are implemented by ContextImpl.
+// Contexts, which do not implement them, work without the feature, e.g. without variables or traces.
+
+// VarsContext is a context with typed variables, e.g. numbers, slices or nested maps.
+type VarsContext interface {
+	// Vars returns the variables of the test. They are available in templates
+	// with their types, e.g. {{range .Vars.cart.items}}.
+	// Steps of the same test share the variables, so that a step can hand over data to the next one.
+	Vars() map[string]interface{}
+
+	// WithVars returns a copy of the context, where the variables are
+	// entry wise overwritten by the supplied values.
+	WithVars(values map[string]interface{}) Context
+
+	// Value returns the value of the key from the variables,
+	// the test data or the env, in this order.
+	Value(key string) (interface{}, bool)
+}
+
+// EnvContext is a context, whose env can be extended.
+type EnvContext interface {
+	// WithEnv returns a copy of the context, where the env is
+	// entry wise overwritten by the supplied env.
+	WithEnv(env map[string]string) Context
+}
+
+// SharedContext is a context with a store for state, which is shared between workers and scenarios.
+type SharedContext interface {
+	// Shared returns the store of the context. It defaults to the store of the
+	// repository within a run and to DefaultSharedStore otherwise.
+	Shared() *SharedStore
+
+	// WithShared returns a copy of the context, which uses the supplied store.
+	WithShared(store *SharedStore) Context
+}
+
+// ExecutionContext is a context, which reports to an execution, e.g. to create child spans of its trace.
+type ExecutionContext interface {
+	// Execution returns the execution, the context reports to,
+	// or nil if the context is not used within a run.
+	Execution() *Execution
+
+	// WithExecution returns a copy of the context, which reports to the supplied execution.
+	WithExecution(execution *Execution) Context
+}
+
+// SecretContext is a context with own secret keys.
+type SecretContext interface {
+	// IsSecret returns true, if the key of the env, test data or variables was marked as secret
+	// for the context by WithSecrets, or for all contexts by MarkSecret.
+	IsSecret(key string) bool
+
+	// WithSecrets returns a copy of the context, where the supplied keys are additionally marked as secret.
+	WithSecrets(keys ...string) Context
+}
+
+// WorkerContext is a context, which knows the worker executing the test.
+type WorkerContext interface {
+	// Worker returns the index of the worker, which executes the test, starting with 0.
+	Worker() int
+
+	// WithWorker returns a copy of the context for the worker with the supplied index.
+	WithWorker(worker int) Context
+}
+
+// CorrelationContext is a context with an own configuration of the correlation ids.
+type CorrelationContext interface {
+	// Correlation returns the configuration, how correlation ids are created and transferred.
+	Correlation() *CorrelationConfig
+
+	// WithCorrelation returns a copy of the context, which uses the supplied
+	// configuration and carries a new correlation id created by it.
+	WithCorrelation(config *CorrelationConfig) Context
+}
+
 type ContextImpl struct {
 	test          map[string]string
 	env           map[string]string
+	vars          map[string]interface{}
+	shared        *SharedStore
 	testNumber    int
 	correlationId string
+	correlation   *CorrelationConfig
+	execution     *Execution
+	secrets       map[string]bool
+	worker        int
+	// feeder hands out the test data, when a worker executes the test, see FeedUniquePerWorker
+	feeder *Feeder
 }
 
 // NewDefaultContext creates a new context without data
@@ -58,6 +143,7 @@ func NewDefaultContext() *ContextImpl {
 	return &ContextImpl{
 		env:           make(map[string]string),
 		test:          make(map[string]string),
+		vars:          make(map[string]interface{}),
 		testNumber:    0,
 		correlationId: "",
 	}
@@ -69,6 +155,7 @@ func NewContext(env map[string]string) *ContextImpl {
 	cntx := &ContextImpl{
 		env:           env,
 		test:          make(map[string]string),
+		vars:          make(map[string]interface{}),
 		testNumber:    0,
 		correlationId: "",
 	}
@@ -86,6 +173,32 @@ func (cntx *ContextImpl) Test() map[string]string {
 	return cntx.test
 }
 
+func (cntx *ContextImpl) Vars() map[string]interface{} {
+	return cntx.vars
+}
+
+// WithVars returns a copy of the context with the supplied variables.
+// The maps and slices of the existing variables are copied, see Derive.
+func (cntx *ContextImpl) WithVars(values map[string]interface{}) Context {
+	contextCopy := *cntx
+	contextCopy.vars = copyVars(cntx.vars, len(values))
+	for k, v := range values {
+		contextCopy.vars[k] = v
+	}
+	return &contextCopy
+}
+
+func (cntx *ContextImpl) Value(key string) (interface{}, bool) {
+	if v, exists := cntx.vars[key]; exists {
+		return v, true
+	}
+	if v, exists := cntx.test[key]; exists {
+		return v, true
+	}
+	v, exists := cntx.env[key]
+	return v, exists
+}
+
 func (cntx *ContextImpl) TestNumber() int {
 	return cntx.testNumber
 }
@@ -113,23 +226,104 @@ func (cntx *ContextImpl) CorrelationId() string {
 	return cntx.correlationId
 }
 
+func (cntx *ContextImpl) Correlation() *CorrelationConfig {
+	if cntx.correlation == nil {
+		return defaultCorrelationConfig
+	}
+	return cntx.correlation
+}
+
+func (cntx *ContextImpl) WithCorrelation(config *CorrelationConfig) Context {
+	contextCopy := *cntx
+	contextCopy.correlation = config
+	contextCopy.correlationId = config.newId(&contextCopy)
+	return &contextCopy
+}
+
+func (cntx *ContextImpl) WithEnv(env map[string]string) Context {
+	contextCopy := *cntx
+	contextCopy.env = make(map[string]string, len(cntx.env)+len(env))
+	for k, v := range cntx.env {
+		contextCopy.env[k] = v
+	}
+	for k, v := range env {
+		contextCopy.env[k] = v
+	}
+	return &contextCopy
+}
+
+func (cntx *ContextImpl) Shared() *SharedStore {
+	if cntx.shared == nil {
+		return defaultSharedStore
+	}
+	return cntx.shared
+}
+
+func (cntx *ContextImpl) WithShared(store *SharedStore) Context {
+	contextCopy := *cntx
+	contextCopy.shared = store
+	return &contextCopy
+}
+
+func (cntx *ContextImpl) Execution() *Execution {
+	return cntx.execution
+}
+
+func (cntx *ContextImpl) WithExecution(execution *Execution) Context {
+	contextCopy := *cntx
+	contextCopy.execution = execution
+	return &contextCopy
+}
+
+func (cntx *ContextImpl) IsSecret(key string) bool {
+	return cntx.secrets[key] || IsSecret(key)
+}
+
+func (cntx *ContextImpl) WithSecrets(keys ...string) Context {
+	contextCopy := *cntx
+	contextCopy.secrets = make(map[string]bool, len(cntx.secrets)+len(keys))
+	for key := range cntx.secrets {
+		contextCopy.secrets[key] = true
+	}
+	for _, key := range keys {
+		contextCopy.secrets[key] = true
+	}
+	return &contextCopy
+}
+
+func (cntx *ContextImpl) Worker() int {
+	return cntx.worker
+}
+
+func (cntx *ContextImpl) WithWorker(worker int) Context {
+	contextCopy := *cntx
+	contextCopy.worker = worker
+	return &contextCopy
+}
+
 func (cntx *ContextImpl) ExpandVars(tpl string) (string, error) {
-	t, err := template.New("template").Parse(tpl)
+	if !strings.Contains(tpl, "{{") {
+		return tpl, nil
+	}
+	t, err := currentTemplates().parseWithStore(tpl, cntx.Shared())
 	if err != nil {
 		return "", err
 	}
 	b := bytes.NewBuffer(nil)
-	err = t.ExecuteTemplate(b, "template", cntx)
+	err = t.Execute(b, cntx)
 	if err != nil {
 		return "", err
 	}
 	return b.String(), nil
 }
 
+// Derive creates a copy of the context with the supplied test data and the next test number.
+// The variables are copied deeply, including nested maps and slices, so that concurrent tests
+// do not share them. Pointers and structs within the variables are shared and have to be treated as read-only.
 func (cntx *ContextImpl) Derive(overrideValues map[string]string) Context {
 	contextCopy := *cntx
 	contextCopy.testNumber++
-	contextCopy.correlationId = randStringBytes(10)
+	contextCopy.execution = nil
 	contextCopy.test = make(map[string]string)
 	for k, v := range cntx.test {
 		contextCopy.test[k] = v
@@ -137,6 +331,8 @@ func (cntx *ContextImpl) Derive(overrideValues map[string]string) Context {
 	for k, v := range overrideValues {
 		contextCopy.test[k] = v
 	}
+	contextCopy.vars = copyVars(cntx.vars, 0)
+	contextCopy.correlationId = cntx.Correlation().newId(&contextCopy)
 	return &contextCopy
 }
 
@@ -153,3 +349,149 @@ func (cntx *ContextImpl) Populate(n int, createTestDataClosure func(testNumber i
 	}()
 	return resultChannel
 }
+
+// varsOf returns the variables of the context, or nil if it has none.
+func varsOf(cntx Context) map[string]interface{} {
+	if c, ok := cntx.(VarsContext); ok {
+		return c.Vars()
+	}
+	return nil
+}
+
+// withEnv returns the context with the env overwritten, if it supports it.
+func withEnv(cntx Context, env map[string]string) Context {
+	if c, ok := cntx.(EnvContext); ok {
+		return c.WithEnv(env)
+	}
+	return cntx
+}
+
+// sharedOf returns the store of the context, or the DefaultSharedStore.
+func sharedOf(cntx Context) *SharedStore {
+	if c, ok := cntx.(SharedContext); ok {
+		return c.Shared()
+	}
+	return defaultSharedStore
+}
+
+// withShared returns the context with the store, if it supports it.
+func withShared(cntx Context, store *SharedStore) Context {
+	if c, ok := cntx.(SharedContext); ok {
+		return c.WithShared(store)
+	}
+	return cntx
+}
+
+// executionOf returns the execution of the context, or nil.
+func executionOf(cntx Context) *Execution {
+	if c, ok := cntx.(ExecutionContext); ok {
+		return c.Execution()
+	}
+	return nil
+}
+
+// withExecution returns the context bound to the execution, if it supports it.
+func withExecution(cntx Context, execution *Execution) Context {
+	if c, ok := cntx.(ExecutionContext); ok {
+		return c.WithExecution(execution)
+	}
+	return cntx
+}
+
+// isSecretIn returns true, if the key is secret for the context or for all contexts.
+func isSecretIn(cntx Context, key string) bool {
+	if c, ok := cntx.(SecretContext); ok {
+		return c.IsSecret(key)
+	}
+	return IsSecret(key)
+}
+
+// withSecrets returns the context with the additional secret keys, if it supports them.
+func withSecrets(cntx Context, keys ...string) Context {
+	if c, ok := cntx.(SecretContext); ok {
+		return c.WithSecrets(keys...)
+	}
+	return cntx
+}
+
+// withWorker returns the context for the worker, if it supports it.
+func withWorker(cntx Context, worker int) Context {
+	if c, ok := cntx.(WorkerContext); ok {
+		return c.WithWorker(worker)
+	}
+	return cntx
+}
+
+// correlationOf returns the correlation configuration of the context, or the default one.
+func correlationOf(cntx Context) *CorrelationConfig {
+	if c, ok := cntx.(CorrelationContext); ok {
+		return c.Correlation()
+	}
+	return defaultCorrelationConfig
+}
+
+// withCorrelation returns the context with the correlation configuration, if it supports it.
+func withCorrelation(cntx Context, config *CorrelationConfig) Context {
+	if c, ok := cntx.(CorrelationContext); ok {
+		return c.WithCorrelation(config)
+	}
+	return cntx
+}
+
+// copyVars returns a deep copy of the variables with space for additional entries.
+func copyVars(vars map[string]interface{}, additional int) map[string]interface{} {
+	c := make(map[string]interface{}, len(vars)+additional)
+	for k, v := range vars {
+		c[k] = deepCopy(v)
+	}
+	return c
+}
+
+// deepCopy copies maps and slices recursively. Other values are returned as they are.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, int, int64, float64:
+		return v
+	case map[string]interface{}:
+		return copyVars(v, 0)
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, element := range v {
+			c[i] = deepCopy(element)
+		}
+		return c
+	}
+	return deepCopyValue(reflect.ValueOf(value)).Interface()
+}
+
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopyValue(v.Elem()))
+		return c
+	}
+	return v
+}
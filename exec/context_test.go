@@ -87,3 +87,111 @@ func Test_RandStringBytes(t *testing.T) {
 	a.Equal(0, len(correlationId3))
 	a.Equal(0, len(correlationId4))
 }
+
+func Test_Context_Vars(t *testing.T) {
+	a := assert.New(t)
+
+	cntx := NewContext(map[string]string{"host": "example.com"})
+	cntx.Test()["user"] = "alice"
+	typed := cntx.WithVars(map[string]interface{}{
+		"count": 3,
+		"cart": map[string]interface{}{
+			"items": []map[string]interface{}{
+				{"name": "apple", "price": 1.5},
+				{"name": "pear", "price": 2},
+			},
+		},
+	})
+
+	result, err := typed.ExpandVars(`{{.Test.user}}:{{range $i, $item := .Vars.cart.items}}{{if $i}},{{end}}{{$item.name}}={{$item.price}}{{end}}{{if gt .Vars.count 2}} many{{end}}`)
+	a.NoError(err)
+	a.Equal("alice:apple=1.5,pear=2 many", result)
+	result, err = typed.ExpandVars(`{{json .Vars.cart}}`)
+	a.NoError(err)
+	a.Equal(`{"items":[{"name":"apple","price":1.5},{"name":"pear","price":2}]}`, result)
+
+	a.Empty(cntx.Vars())
+	derived := typed.Derive(nil)
+	derived.(VarsContext).Vars()["count"] = 4
+	a.Equal(3, typed.(VarsContext).Vars()["count"])
+
+	v, exists := derived.(VarsContext).Value("count")
+	a.True(exists)
+	a.Equal(4, v)
+	v, _ = derived.(VarsContext).Value("user")
+	a.Equal("alice", v)
+	v, _ = derived.(VarsContext).Value("host")
+	a.Equal("example.com", v)
+	_, exists = derived.(VarsContext).Value("missing")
+	a.False(exists)
+}
+
+func Test_Context_VarsAreCopiedDeeply(t *testing.T) {
+	a := assert.New(t)
+
+	base := NewDefaultContext().WithVars(map[string]interface{}{
+		"cart": map[string]interface{}{
+			"items": []map[string]interface{}{{"name": "apple"}},
+			"tags":  []interface{}{"new"},
+		},
+		"ids": map[string][]int{"a": {1}},
+	})
+	derived := base.Derive(nil)
+	cart := derived.(VarsContext).Vars()["cart"].(map[string]interface{})
+	cart["items"].([]map[string]interface{})[0]["name"] = "pear"
+	cart["tags"].([]interface{})[0] = "old"
+	cart["total"] = 3
+	derived.(VarsContext).Vars()["ids"].(map[string][]int)["a"][0] = 2
+
+	baseCart := base.(VarsContext).Vars()["cart"].(map[string]interface{})
+	a.Equal("apple", baseCart["items"].([]map[string]interface{})[0]["name"])
+	a.Equal("new", baseCart["tags"].([]interface{})[0])
+	a.NotContains(baseCart, "total")
+	a.Equal(1, base.(VarsContext).Vars()["ids"].(map[string][]int)["a"][0])
+
+	other := base.(VarsContext).WithVars(map[string]interface{}{"count": 1})
+	other.(VarsContext).Vars()["cart"].(map[string]interface{})["total"] = 5
+	a.NotContains(baseCart, "total")
+	a.NotNil(NewContext(nil).Vars())
+}
+
+// minimalContext implements only the Context interface, like a context of another package.
+type minimalContext struct {
+	Context
+}
+
+func Test_Context_WithoutOptionalInterfaces(t *testing.T) {
+	a := assert.New(t)
+	var cntx Context = minimalContext{NewContext(map[string]string{"host": "example.com"})}
+
+	a.Nil(varsOf(cntx))
+	a.Nil(executionOf(cntx))
+	a.Equal(DefaultCorrelationConfig(), correlationOf(cntx))
+	a.True(sharedOf(cntx) == DefaultSharedStore())
+	a.True(withEnv(cntx, map[string]string{"host": "other"}) == cntx)
+
+	execution := StartExecution("get", &cntx)
+	execution.End(nil)
+	a.Equal("get", execution.Name())
+	a.Equal("example.com", cntx.Env()["host"])
+}
+
+func Test_Context_ZeroValue(t *testing.T) {
+	a := assert.New(t)
+	cntx := &ContextImpl{}
+
+	a.Empty(cntx.Vars())
+	_, exists := cntx.Value("count")
+	a.False(exists)
+
+	typed := cntx.WithVars(map[string]interface{}{"count": 1})
+	a.Equal(map[string]interface{}{"count": 1}, typed.(VarsContext).Vars())
+	a.Empty(cntx.Vars())
+
+	derived := cntx.Derive(map[string]string{"user": "alice"})
+	derived.(VarsContext).Vars()["count"] = 2
+	a.Equal(1, derived.TestNumber())
+	a.Equal("alice", derived.Test()["user"])
+	a.Empty(cntx.Vars())
+	a.Equal("alice-2", derived.ExpandVarsNoError("{{.Test.user}}-{{.Vars.count}}"))
+}
@@ -0,0 +1,127 @@
+package exec
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCorrelationHeader is the http header, which transfers the correlation id by default.
+const DefaultCorrelationHeader = "X-Correlation-Id"
+
+// CorrelationIdGenerator creates a new correlation id.
+// It is called with the context, which will carry the id.
+type CorrelationIdGenerator func(cntx Context) string
+
+// CorrelationConfig defines how correlation ids are created and transferred.
+type CorrelationConfig struct {
+	// Generator creates the ids. It defaults to RandomCorrelationIds(10).
+	Generator CorrelationIdGenerator
+	// Headers are the http headers, which transfer the id.
+	// It defaults to DefaultCorrelationHeader.
+	Headers []string
+	// PerStep creates a new id for each step of a sequence,
+	// instead of one id per iteration.
+	PerStep bool
+}
+
+var defaultCorrelationConfig = &CorrelationConfig{
+	Generator: RandomCorrelationIds(10),
+	Headers:   []string{DefaultCorrelationHeader},
+}
+
+// DefaultCorrelationConfig returns the configuration, which is used if nothing else is configured.
+func DefaultCorrelationConfig() *CorrelationConfig {
+	return defaultCorrelationConfig
+}
+
+func (config *CorrelationConfig) newId(cntx Context) string {
+	if config.Generator == nil {
+		return defaultCorrelationConfig.Generator(cntx)
+	}
+	return config.Generator(cntx)
+}
+
+func (config *CorrelationConfig) headers() []string {
+	if config.Headers == nil {
+		return defaultCorrelationConfig.Headers
+	}
+	return config.Headers
+}
+
+// RandomCorrelationIds creates random alphanumeric ids of the supplied length.
+func RandomCorrelationIds(length int) CorrelationIdGenerator {
+	return func(cntx Context) string {
+		return randStringBytes(length)
+	}
+}
+
+// UUIDCorrelationIds creates random UUIDs (version 4).
+func UUIDCorrelationIds() CorrelationIdGenerator {
+	return func(cntx Context) string {
+		return newUUID()
+	}
+}
+
+// ULIDCorrelationIds creates ULIDs, which are lexicographically sortable by their creation time.
+func ULIDCorrelationIds() CorrelationIdGenerator {
+	return func(cntx Context) string {
+		return newULID(time.Now())
+	}
+}
+
+// CounterCorrelationIds creates ids by the prefix and a counter, starting with 1.
+// Each generator has its own counter.
+func CounterCorrelationIds(prefix string) CorrelationIdGenerator {
+	var counter int64
+	return func(cntx Context) string {
+		return prefix + strconv.FormatInt(atomic.AddInt64(&counter, 1), 10)
+	}
+}
+
+// TemplateCorrelationIds creates ids by expanding the template with the context,
+// e.g. "{{.Env.run}}-{{.TestNumber}}".
+func TemplateCorrelationIds(template string) CorrelationIdGenerator {
+	return func(cntx Context) string {
+		return cntx.ExpandVarsNoError(template)
+	}
+}
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func newULID(t time.Time) string {
+	var b [16]byte
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	rand.Read(b[6:])
+
+	// 128 bits are encoded in 26 characters of 5 bits, with 2 leading zero bits
+	id := make([]byte, 26)
+	var acc uint32
+	bits := 2
+	pos := 0
+	for _, v := range b {
+		acc = acc<<8 | uint32(v)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			id[pos] = crockfordBase32[(acc>>uint(bits))&0x1f]
+			pos++
+		}
+	}
+	return string(id)
+}
@@ -0,0 +1,81 @@
+package exec
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_CorrelationIdGenerators(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewContext(map[string]string{"run": "nightly"})
+
+	a.Regexp(`^[a-zA-Z0-9]{12}$`, RandomCorrelationIds(12)(cntx))
+	a.Regexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, UUIDCorrelationIds()(cntx))
+	a.NotEqual(UUIDCorrelationIds()(cntx), UUIDCorrelationIds()(cntx))
+
+	ulid := ULIDCorrelationIds()(cntx)
+	a.Regexp(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`, ulid)
+	a.True(ulid < newULID(time.Now().Add(time.Second)))
+	a.Equal("0000000000", newULID(time.Unix(0, 0))[:10])
+	a.Equal("01ARYZ6S41", newULID(time.Unix(0, 1469918176385*int64(time.Millisecond)))[:10])
+
+	counter := CounterCorrelationIds("order-")
+	a.Equal("order-1", counter(cntx))
+	a.Equal("order-2", counter(cntx))
+	a.Equal("order-1", CounterCorrelationIds("order-")(cntx))
+
+	derived := cntx.WithCorrelation(&CorrelationConfig{Generator: TemplateCorrelationIds("{{.Env.run}}-{{.TestNumber}}")})
+	a.Equal("nightly-0", derived.CorrelationId())
+	a.Equal("nightly-1", derived.Derive(nil).CorrelationId())
+	a.Equal("nightly-2", derived.Derive(nil).Derive(nil).CorrelationId())
+}
+
+func Test_Correlation_Defaults(t *testing.T) {
+	a := assert.New(t)
+
+	cntx := &ContextImpl{}
+	a.Equal(DefaultCorrelationConfig(), cntx.Correlation())
+	a.Regexp(`^[a-zA-Z0-9]{10}$`, cntx.Derive(nil).CorrelationId())
+
+	derived := cntx.WithCorrelation(&CorrelationConfig{PerStep: true})
+	a.Regexp(`^[a-zA-Z0-9]{10}$`, derived.CorrelationId())
+	a.Equal([]string{DefaultCorrelationHeader}, derived.(CorrelationContext).Correlation().headers())
+}
+
+func Test_Correlation_Repository(t *testing.T) {
+	a := assert.New(t)
+
+	headers := make(chan http.Header, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+	}))
+	defer server.Close()
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.SetCorrelation(&CorrelationConfig{
+		Generator: CounterCorrelationIds("repo-"),
+		Headers:   []string{"X-Request-Id", "X-Trace"},
+	})
+	repo.MustAdd(NewTestScenario("repo", Get(server.URL), newChannelFactory()), "correlation", 1)
+	repo.MustAdd(NewTestScenario("scenario", Seq("seq", Get(server.URL), Get(server.URL)), newChannelFactory()).
+		WithCorrelation(&CorrelationConfig{Generator: CounterCorrelationIds("step-"), PerStep: true}),
+		"correlation", 1)
+	repo.RunTestScenarios("correlation", "")
+
+	h := <-headers
+	a.Equal("repo-1", h.Get("X-Request-Id"))
+	a.Equal("repo-1", h.Get("X-Trace"))
+	a.Equal("", h.Get(DefaultCorrelationHeader))
+	a.Equal("repo-1", repo.lastReport.Scenarios[0].Executions[0].Context().CorrelationId())
+
+	a.Equal("step-2", (<-headers).Get(DefaultCorrelationHeader))
+	a.Equal("step-3", (<-headers).Get(DefaultCorrelationHeader))
+	execution := repo.lastReport.Scenarios[1].Executions[0]
+	a.Equal("step-1", execution.Context().CorrelationId())
+	a.Equal("step-2", execution.Steps()[0].Context().CorrelationId())
+	a.Equal("step-3", execution.Steps()[1].Context().CorrelationId())
+}
@@ -0,0 +1,382 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefinitionFormat is the file format of scenario definitions.
+type DefinitionFormat string
+
+const (
+	FormatYAML DefinitionFormat = "yaml"
+	FormatJSON DefinitionFormat = "json"
+)
+
+// DefinitionFile is the declarative form of scenarios, as read from YAML or JSON:
+//
+//	env:
+//	  host: http://localhost:8080
+//	secrets: [password]
+//	scenarios:
+//	  - name: login
+//	    group: smoke
+//	    tags: [fast]
+//	    concurrency: 2
+//	    dependsOn: [seed-data]
+//	    iterations: 10
+//	    testData:
+//	      - user: alice
+//	      - user: bob
+//	    thresholds: ["p95 < 300ms", "error_rate < 1%"]
+//	    steps:
+//	      - name: home
+//	        url: "{{.Env.host}}/"
+//	        expect:
+//	          contentType: text/html
+//	          selectors:
+//	            - selector: h1
+//	              contains: Welcome
+//	      - name: login
+//	        method: POST
+//	        url: "{{.Env.host}}/login"
+//	        headers:
+//	          Content-Type: application/json
+//	        body: '{"user": "{{.Test.user}}"}'
+//	        expect:
+//	          code: 200
+//	      - func: clearCookies
+type DefinitionFile struct {
+	// Env is the environment of all scenarios of the file.
+	Env map[string]string `yaml:"env" json:"env"`
+	// Secrets are the keys of the env and test data, which are marked by Repository.MarkSecret.
+	Secrets   []string              `yaml:"secrets" json:"secrets"`
+	Scenarios []*ScenarioDefinition `yaml:"scenarios" json:"scenarios"`
+}
+
+// ScenarioDefinition defines a scenario and its registration in the repository.
+type ScenarioDefinition struct {
+	Name        string   `yaml:"name" json:"name"`
+	Group       string   `yaml:"group" json:"group"`
+	Tags        []string `yaml:"tags" json:"tags"`
+	Concurrency int      `yaml:"concurrency" json:"concurrency"`
+	// DependsOn are the names of the scenarios, which have to pass before this one runs.
+	DependsOn []string `yaml:"dependsOn" json:"dependsOn"`
+	// Env is merged into the env of the file.
+	Env map[string]string `yaml:"env" json:"env"`
+	// TestData contains the test data for each iteration.
+	TestData []map[string]string `yaml:"testData" json:"testData"`
+	// Iterations is the number of executions. It defaults to the number of
+	// test data entries, which are reused round robin for more iterations, or 1.
+	Iterations int `yaml:"iterations" json:"iterations"`
+	// Thresholds are threshold expressions, as parsed by ParseThreshold.
+	Thresholds []string          `yaml:"thresholds" json:"thresholds"`
+	Steps      []*StepDefinition `yaml:"steps" json:"steps"`
+}
+
+// StepDefinition defines a step. It is either a http request with url,
+// a registered function referenced by func, or a sequence of nested steps.
+type StepDefinition struct {
+	Name string `yaml:"name" json:"name"`
+	// Func is the name of a function, registered at the DefinitionLoader.
+	Func    string            `yaml:"func" json:"func"`
+	Method  string            `yaml:"method" json:"method"`
+	Url     string            `yaml:"url" json:"url"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+	Body    string            `yaml:"body" json:"body"`
+	// BasicAuth contains the username and password for basic authentication.
+	BasicAuth *BasicAuthDefinition `yaml:"basicAuth" json:"basicAuth"`
+	Expect    *ExpectDefinition    `yaml:"expect" json:"expect"`
+	Steps     []*StepDefinition    `yaml:"steps" json:"steps"`
+}
+
+// BasicAuthDefinition defines the credentials of a http request.
+type BasicAuthDefinition struct {
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// ExpectDefinition defines the expectations on a http response.
+// Without code or codeRange, the response code has to be 200.
+type ExpectDefinition struct {
+	Code        int                  `yaml:"code" json:"code"`
+	CodeRange   []int                `yaml:"codeRange" json:"codeRange"`
+	ContentType string               `yaml:"contentType" json:"contentType"`
+	Contains    []string             `yaml:"contains" json:"contains"`
+	Selectors   []SelectorDefinition `yaml:"selectors" json:"selectors"`
+}
+
+// SelectorDefinition expects the text of a selection to contain a substring.
+type SelectorDefinition struct {
+	Selector string `yaml:"selector" json:"selector"`
+	Contains string `yaml:"contains" json:"contains"`
+}
+
+// DefinitionLoader creates scenarios from definitions and adds them to a repository.
+// Steps implemented in go are registered by name and referenced by func in the definitions.
+type DefinitionLoader struct {
+	steps map[string]Exec
+}
+
+// NewDefinitionLoader creates a loader without registered steps.
+func NewDefinitionLoader() *DefinitionLoader {
+	return &DefinitionLoader{
+		steps: make(map[string]Exec),
+	}
+}
+
+// Register registers the functions by their name.
+func (loader *DefinitionLoader) Register(funcs ...*FuncExec) *DefinitionLoader {
+	for _, f := range funcs {
+		loader.RegisterExec(f.Name(), f)
+	}
+	return loader
+}
+
+// RegisterExec registers a step under the supplied name.
+func (loader *DefinitionLoader) RegisterExec(name string, step Exec) *DefinitionLoader {
+	loader.steps[name] = step
+	return loader
+}
+
+// DefinitionFormatOf returns the format for a file name by its extension.
+// Files ending on .json are JSON, all others YAML.
+func DefinitionFormatOf(path string) DefinitionFormat {
+	if filepath.Ext(path) == ".json" {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// ParseDefinitions reads a definition file. Unknown fields are reported as error.
+func ParseDefinitions(r io.Reader, format DefinitionFormat) (*DefinitionFile, error) {
+	file := &DefinitionFile{}
+	if format == FormatJSON {
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(file); err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(b, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// LoadFile reads the definitions from the file at path, with the format
+// detected by DefinitionFormatOf, and adds the scenarios to the repository.
+func (loader *DefinitionLoader) LoadFile(repo *Repository, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := loader.Load(repo, bytes.NewReader(b), DefinitionFormatOf(path)); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	return nil
+}
+
+// Load reads the definitions and adds the scenarios to the repository.
+// If one of the scenarios is invalid, none is added.
+func (loader *DefinitionLoader) Load(repo *Repository, r io.Reader, format DefinitionFormat) error {
+	file, err := ParseDefinitions(r, format)
+	if err != nil {
+		return err
+	}
+	return loader.Add(repo, file)
+}
+
+// Add adds the scenarios of the definition file to the repository.
+// If one of the scenarios is invalid, none is added.
+func (loader *DefinitionLoader) Add(repo *Repository, file *DefinitionFile) error {
+	scenarios := make([]*TestScenario, len(file.Scenarios))
+	for i, definition := range file.Scenarios {
+		scenario, err := loader.Scenario(definition, file.Env)
+		if err != nil {
+			return err
+		}
+		scenarios[i] = scenario
+	}
+	repo.MarkSecret(file.Secrets...)
+	for i, definition := range file.Scenarios {
+		concurrency := definition.Concurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		if err := repo.Add(scenarios[i], definition.Group, concurrency, definition.Tags...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scenario creates the scenario of a definition with the supplied env,
+// which is overwritten by the env of the definition.
+func (loader *DefinitionLoader) Scenario(definition *ScenarioDefinition, env map[string]string) (*TestScenario, error) {
+	if definition.Name == "" {
+		return nil, fmt.Errorf("scenario without name")
+	}
+	if len(definition.Steps) == 0 {
+		return nil, fmt.Errorf("scenario %q: no steps", definition.Name)
+	}
+	steps := make([]Exec, len(definition.Steps))
+	for i, stepDefinition := range definition.Steps {
+		step, err := loader.step(stepDefinition)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %q: step %v: %v", definition.Name, i+1, err)
+		}
+		steps[i] = step
+	}
+
+	var scenarioExec Exec = Seq(definition.Name, steps...)
+	if len(steps) == 1 {
+		scenarioExec = steps[0]
+	}
+	if err := Validate(scenarioExec); err != nil {
+		return nil, fmt.Errorf("scenario %q: %v", definition.Name, err)
+	}
+
+	mergedEnv := map[string]string{}
+	for k, v := range env {
+		mergedEnv[k] = v
+	}
+	for k, v := range definition.Env {
+		mergedEnv[k] = v
+	}
+
+	iterations := definition.Iterations
+	if iterations < 1 {
+		iterations = len(definition.TestData)
+	}
+	if iterations < 1 {
+		iterations = 1
+	}
+	testData := definition.TestData
+	contextChannelFactory := func() chan Context {
+		cntx := NewContext(copyStringMap(mergedEnv))
+		return cntx.Populate(iterations, func(testNumber int) map[string]string {
+			if len(testData) == 0 {
+				return nil
+			}
+			return testData[(testNumber-1)%len(testData)]
+		})
+	}
+
+	scenario := NewTestScenario(definition.Name, scenarioExec, contextChannelFactory)
+	scenario.ExpectedExecutions = iterations
+	scenario.DependsOn(definition.DependsOn...)
+	for _, expression := range definition.Thresholds {
+		threshold, err := ParseThreshold(expression)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %q: %v", definition.Name, err)
+		}
+		scenario.WithThresholds(threshold)
+	}
+	return scenario, nil
+}
+
+func (loader *DefinitionLoader) step(definition *StepDefinition) (Exec, error) {
+	kinds := 0
+	for _, set := range []bool{definition.Func != "", definition.Url != "", len(definition.Steps) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, fmt.Errorf("exactly one of func, url or steps required")
+	}
+
+	switch {
+	case definition.Func != "":
+		step, exists := loader.steps[definition.Func]
+		if !exists {
+			return nil, fmt.Errorf("unknown func %q", definition.Func)
+		}
+		return step, nil
+	case len(definition.Steps) > 0:
+		seq := Seq(definition.Name)
+		for i, stepDefinition := range definition.Steps {
+			step, err := loader.step(stepDefinition)
+			if err != nil {
+				return nil, fmt.Errorf("%v.%v: %v", definition.Name, i+1, err)
+			}
+			seq.Add(step)
+		}
+		return seq, nil
+	}
+	return definition.httpExec()
+}
+
+func (definition *StepDefinition) httpExec() (*HttpExec, error) {
+	method := strings.ToUpper(definition.Method)
+	if method == "" {
+		method = "GET"
+	}
+	httpExec := &HttpExec{
+		Method: method,
+		Url:    definition.Url,
+		Header: http.Header{},
+		Body:   []byte(definition.Body),
+	}
+	keys := make([]string, 0, len(definition.Headers))
+	for k := range definition.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		httpExec.Header.Set(k, definition.Headers[k])
+	}
+	if definition.BasicAuth != nil {
+		httpExec.WithBasicAuth(definition.BasicAuth.Username, definition.BasicAuth.Password)
+	}
+	if definition.Name != "" {
+		httpExec.Named(definition.Name)
+	}
+
+	expect := definition.Expect
+	if expect == nil {
+		return httpExec, nil
+	}
+	if expect.Code != 0 {
+		httpExec.HasCode(expect.Code)
+	}
+	if expect.CodeRange != nil {
+		if len(expect.CodeRange) != 2 {
+			return nil, fmt.Errorf("codeRange needs min and max, but was %v", expect.CodeRange)
+		}
+		httpExec.HasCodeRange(expect.CodeRange[0], expect.CodeRange[1])
+	}
+	if expect.ContentType != "" {
+		httpExec.HasContentType(expect.ContentType)
+	}
+	for _, substring := range expect.Contains {
+		httpExec.Contains(substring)
+	}
+	for _, selector := range expect.Selectors {
+		httpExec.SelectorContains(selector.Selector, selector.Contains)
+	}
+	return httpExec, nil
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,205 @@
+package exec
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var yamlDefinitions = `
+env:
+  host: HOST
+secrets: [definitionTestPassword]
+scenarios:
+  - name: login
+    group: smoke
+    tags: [fast, login]
+    concurrency: 2
+    dependsOn: [status]
+    iterations: 4
+    testData:
+      - user: alice
+      - user: bob
+    thresholds: ["error_rate < 1%"]
+    steps:
+      - name: home
+        url: "{{.Env.host}}/"
+        expect:
+          contentType: text/html
+          selectors:
+            - selector: "#foo"
+              contains: bar
+      - name: login
+        method: post
+        url: "{{.Env.host}}/login"
+        headers:
+          Content-Type: text/plain
+        body: "{{.Test.user}}"
+        basicAuth:
+          username: admin
+          password: secret
+        expect:
+          code: 200
+          contains: [ok]
+      - func: count
+`
+
+var jsonDefinitions = `{
+  "scenarios": [{
+    "name": "status",
+    "group": "smoke",
+    "env": {"host": "HOST"},
+    "steps": [{"name": "nested", "steps": [{"url": "{{.Env.host}}/missing", "expect": {"codeRange": [400, 499]}}]}]
+  }]
+}`
+
+func newDefinitionServer(users *[]string, mutex *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/":
+			resp.Header().Set("Content-Type", "text/html")
+			resp.Write([]byte(html))
+		case "/login":
+			user, password, _ := req.BasicAuth()
+			body, _ := ioutil.ReadAll(req.Body)
+			if req.Method != "POST" || user != "admin" || password != "secret" {
+				resp.WriteHeader(http.StatusForbidden)
+				return
+			}
+			mutex.Lock()
+			*users = append(*users, string(body))
+			mutex.Unlock()
+			resp.Write([]byte("ok"))
+		default:
+			resp.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func Test_Definition_LoadYAML(t *testing.T) {
+	a := assert.New(t)
+	users := []string{}
+	mutex := &sync.Mutex{}
+	server := newDefinitionServer(&users, mutex)
+	defer server.Close()
+
+	counted := 0
+	loader := NewDefinitionLoader().Register(F("count", func() error {
+		mutex.Lock()
+		counted++
+		mutex.Unlock()
+		return nil
+	}))
+	repo := NewRepository()
+	repo.SetReporters()
+	err := loader.Load(repo, strings.NewReader(strings.Replace(yamlDefinitions, "HOST", server.URL, 1)), FormatYAML)
+	a.NoError(err)
+	a.Equal([]string{"definitionTestPassword"}, repo.secrets)
+	a.False(IsSecret("definitionTestPassword"))
+
+	a.Equal([]ScenarioInfo{{Name: "login", TestGroup: "smoke", Tags: []string{"fast", "login"}, Concurrency: 2, DependsOn: []string{"status"}}}, repo.Scenarios("", ""))
+
+	a.NoError(loader.Load(repo, strings.NewReader(strings.Replace(jsonDefinitions, "HOST", server.URL, 1)), FormatJSON))
+	repo.RunTestScenarios("", "login")
+	a.Empty(repo.GetFailedThresholds())
+	a.Equal("status", repo.lastReport.Scenarios[0].Run.Name)
+	login := repo.lastReport.Scenarios[1].Run
+	a.Equal(4, login.Stats.Total().Count)
+	a.Equal(0, login.Stats.Total().Errors)
+	a.ElementsMatch([]string{"alice", "bob", "alice", "bob"}, users)
+	a.Equal(4, counted)
+	_, exists := login.Stats.Step("home")
+	a.True(exists)
+}
+
+func Test_Definition_LoadJSONFile(t *testing.T) {
+	a := assert.New(t)
+	users := []string{}
+	server := newDefinitionServer(&users, &sync.Mutex{})
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "godriver-definition")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "scenarios.json")
+	a.NoError(ioutil.WriteFile(path, []byte(strings.Replace(jsonDefinitions, "HOST", server.URL, 1)), 0644))
+
+	repo := NewRepository()
+	repo.SetReporters()
+	a.NoError(NewDefinitionLoader().LoadFile(repo, path))
+	repo.RunTestScenarios("", "status")
+
+	a.Equal(1, repo.lastReport.Scenarios[0].Run.Stats.Total().Count)
+	a.Equal(0, repo.lastReport.Scenarios[0].Run.Stats.Total().Errors)
+	a.Equal(1, repo.Scenarios("", "")[0].Concurrency)
+}
+
+func Test_Definition_BasicAuthFromEnv(t *testing.T) {
+	a := assert.New(t)
+	users := []string{}
+	server := newDefinitionServer(&users, &sync.Mutex{})
+	defer server.Close()
+
+	definitions := `
+env:
+  host: HOST
+scenarios:
+  - name: login
+    testData: [{user: alice}]
+    steps:
+      - url: "{{.Env.host}}/login"
+        method: post
+        body: "{{.Test.user}}"
+        basicAuth:
+          username: "{{.Env.user}}"
+          password: "{{.Env.password}}"
+`
+	repo := NewRepository()
+	repo.SetReporters()
+	a.NoError(NewDefinitionLoader().Load(repo, strings.NewReader(strings.Replace(definitions, "HOST", server.URL, 1)), FormatYAML))
+
+	repo.SetEnv(map[string]string{"user": "admin", "password": "secret"})
+	report, err := repo.RunTestScenarios("", "login")
+	a.NoError(err)
+	a.Equal(0, report.Errors())
+	a.Equal([]string{"alice"}, users)
+
+	repo.SetEnv(map[string]string{"user": "admin", "password": "wrong"})
+	report, err = repo.RunTestScenarios("", "login")
+	a.NoError(err)
+	a.Equal(1, report.Errors())
+	a.NotContains(report.ErrorExecutions()[0].Error().Error(), "wrong")
+}
+
+func Test_Definition_Errors(t *testing.T) {
+	a := assert.New(t)
+
+	load := func(definitions string) error {
+		return NewDefinitionLoader().
+			Register(F("known", func() error { return errors.New("not called") })).
+			Load(NewRepository(), strings.NewReader(definitions), FormatYAML)
+	}
+
+	a.NoError(load("scenarios: [{name: a, steps: [{func: known}]}]"))
+	a.EqualError(load("scenarios: [{name: a, steps: [{func: unknown}]}]"), `scenario "a": step 1: unknown func "unknown"`)
+	a.EqualError(load("scenarios: [{name: a, steps: [{func: known, url: /}]}]"), `scenario "a": step 1: exactly one of func, url or steps required`)
+	a.EqualError(load("scenarios: [{name: a, steps: [{name: s, steps: [{}]}]}]"), `scenario "a": step 1: s.1: exactly one of func, url or steps required`)
+	a.EqualError(load("scenarios: [{name: a}]"), `scenario "a": no steps`)
+	a.EqualError(load("scenarios: [{steps: [{func: known}]}]"), `scenario without name`)
+	a.Error(load("scenarios: [{name: a, thresholds: [foo], steps: [{func: known}]}]"))
+	a.Error(load("scenarios: [{name: a, unknown: 1, steps: [{func: known}]}]"))
+	a.EqualError(load("scenarios: [{name: a, steps: [{name: home, url: '{{.Env.host'}]}]"), `scenario "a": home: url: template: template:1: unclosed action`)
+
+	repo := NewRepository()
+	err := NewDefinitionLoader().Load(repo, strings.NewReader(`{"scenarios": [{"name": "a", "steps": [{"url": "/"}]}, {"name": "b"}]}`), FormatJSON)
+	a.Error(err)
+	a.Empty(repo.Scenarios("", ""))
+}
@@ -0,0 +1,76 @@
+package exec
+
+import (
+	"fmt"
+)
+
+// scheduleScenarios adds the dependencies of the selected scenarios, also if they do not match
+// the filter criteria, and orders them, so that each scenario follows its dependencies.
+// Apart from that, the order of registration is kept.
+// Scenarios with unknown or cyclic dependencies are returned with the reason to skip them.
+func (repo *Repository) scheduleScenarios(selected []*repositoryEntry) ([]*repositoryEntry, map[*repositoryEntry]string) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[*repositoryEntry]int{}
+	skipReasons := map[*repositoryEntry]string{}
+	ordered := []*repositoryEntry{}
+
+	var visit func(t *repositoryEntry)
+	visit = func(t *repositoryEntry) {
+		if state[t] != 0 {
+			return
+		}
+		state[t] = visiting
+		for _, name := range t.testScenario.Dependencies {
+			dependencies := repo.scenariosNamed(name)
+			if len(dependencies) == 0 {
+				skipReasons[t] = fmt.Sprintf("unknown dependency %q", name)
+			}
+			for _, dependency := range dependencies {
+				if state[dependency] == visiting {
+					skipReasons[t] = fmt.Sprintf("dependency cycle with %q", name)
+					continue
+				}
+				visit(dependency)
+			}
+		}
+		state[t] = visited
+		ordered = append(ordered, t)
+	}
+	for _, t := range selected {
+		visit(t)
+	}
+	return ordered, skipReasons
+}
+
+// scenariosNamed returns all scenarios of the name.
+func (repo *Repository) scenariosNamed(name string) []*repositoryEntry {
+	entries := []*repositoryEntry{}
+	for _, t := range repo.testScenarios {
+		if t.testScenario.Name == name {
+			entries = append(entries, t)
+		}
+	}
+	return entries
+}
+
+// dependencySkipReason returns the reason to skip the scenario, because one of its
+// dependencies failed or was skipped, or the empty string, if all of them passed.
+func (repo *Repository) dependencySkipReason(t *repositoryEntry, state *runState) string {
+	state.runsMutex.Lock()
+	defer state.runsMutex.Unlock()
+	for _, name := range t.testScenario.Dependencies {
+		for _, dependency := range repo.scenariosNamed(name) {
+			run, exists := state.runs[dependency]
+			switch {
+			case !exists || run.Skipped():
+				return fmt.Sprintf("dependency %q was skipped", name)
+			case !run.Passed():
+				return fmt.Sprintf("dependency %q failed", name)
+			}
+		}
+	}
+	return ""
+}
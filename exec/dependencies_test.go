@@ -0,0 +1,99 @@
+package exec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Dependencies_RunPrerequisitesFirst(t *testing.T) {
+	a := assert.New(t)
+	reporter := &recordingReporter{}
+
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("orders", newMockExec("orders"), newChannelFactory()).DependsOn("login", "seed-data"), "api", 1)
+	repo.MustAdd(NewTestScenario("login", newMockExec("login"), newChannelFactory()).DependsOn("seed-data"), "api", 1)
+	repo.MustAdd(NewTestScenario("seed-data", newMockExec("seed-data"), newChannelFactory()), "setup", 1)
+	repo.MustAdd(NewTestScenario("other", newMockExec("other"), newChannelFactory()), "api", 1)
+
+	mockResult = ""
+	repo.RunTestScenarios("api", "orders")
+	a.Equal("seed-data,login,orders", mockResult)
+
+	mockResult = ""
+	repo.RunTestScenarios("api", "")
+	a.Equal("seed-data,login,orders,other", mockResult)
+	a.Equal([]string{"login", "seed-data"}, repo.Scenarios("", "orders")[0].DependsOn)
+}
+
+func Test_Dependencies_SkipOnFailure(t *testing.T) {
+	a := assert.New(t)
+	reporter := &recordingReporter{}
+
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("seed-data", newMockErrorExecution("seed-data"), newChannelFactory()), "setup", 1)
+	repo.MustAdd(NewTestScenario("login", newMockExec("login"), newChannelFactory()).DependsOn("seed-data"), "api", 1)
+	repo.MustAdd(NewTestScenario("orders", newMockExec("orders"), newChannelFactory()).DependsOn("login"), "api", 1)
+	repo.MustAdd(NewTestScenario("slow", newMockExec("slow"), newChannelFactory()).
+		WithThresholds(MustThreshold("p50 < 0ms")), "setup", 1)
+	repo.MustAdd(NewTestScenario("report", newMockExec("report"), newChannelFactory()).DependsOn("slow"), "api", 1)
+
+	mockResult = ""
+	repo.RunTestScenarios("", "")
+
+	a.Equal("slow", mockResult)
+	a.Equal([]string{
+		"started setup/seed-data",
+		"report setup/seed-data seed-data",
+		"finished setup/seed-data",
+		`skipped api/login: dependency "seed-data" failed`,
+		`skipped api/orders: dependency "login" was skipped`,
+		"started setup/slow",
+		"report setup/slow slow",
+		"finished setup/slow",
+		`skipped api/report: dependency "slow" failed`,
+	}, reporter.events)
+	a.True(repo.lastReport.Scenarios[1].Run.Skipped())
+	a.False(repo.lastReport.Scenarios[1].Run.Passed())
+	a.Equal(1, len(repo.GetFailedThresholds()))
+}
+
+func Test_Dependencies_InvalidDependencies(t *testing.T) {
+	a := assert.New(t)
+	reporter := &recordingReporter{}
+
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("unknown", newMockExec("unknown"), newChannelFactory()).DependsOn("missing"), "group", 1)
+	repo.MustAdd(NewTestScenario("a", newMockExec("a"), newChannelFactory()).DependsOn("b"), "group", 1)
+	repo.MustAdd(NewTestScenario("b", newMockExec("b"), newChannelFactory()).DependsOn("a"), "group", 1)
+
+	mockResult = ""
+	repo.RunTestScenarios("", "")
+
+	a.Equal("", mockResult)
+	a.Equal([]string{
+		`skipped group/unknown: unknown dependency "missing"`,
+		`skipped group/b: dependency cycle with "a"`,
+		`skipped group/a: dependency "b" was skipped`,
+	}, reporter.events)
+}
+
+func Test_Dependencies_SkippedReports(t *testing.T) {
+	a := assert.New(t)
+	console := bytes.NewBuffer(nil)
+	junit := bytes.NewBuffer(nil)
+
+	repo := NewRepository()
+	repo.SetReporters(NewConsoleReporter(console), NewJUnitWriterReporter(junit))
+	repo.MustAdd(NewTestScenario("skipped", newMockExec("skipped"), newChannelFactory()).DependsOn("missing"), "group", 1)
+	repo.RunTestScenarios("", "")
+
+	a.Contains(console.String(), `skipped group/skipped: unknown dependency "missing"`)
+	a.True(strings.Contains(junit.String(), `<testsuite name="group" tests="1" failures="0" errors="0" skipped="1"`), junit.String())
+	a.Contains(junit.String(), `<skipped message="unknown dependency &#34;missing&#34;"></skipped>`)
+}
@@ -0,0 +1,254 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileVariable is the OS environment variable, which selects the profile,
+// if the EnvLoader has none.
+const ProfileVariable = "GODRIVER_PROFILE"
+
+// DefaultEnvPrefix is the prefix of the OS environment variables,
+// which are loaded into the env.
+const DefaultEnvPrefix = "GODRIVER_ENV_"
+
+// ProfileKey is the env key, which contains the name of the loaded profile.
+const ProfileKey = "profile"
+
+// EnvLoader loads the env of a profile. The sources are merged in the following order,
+// where later sources override earlier ones:
+//
+//	env.yaml, env.yml or env.json            common values of all profiles
+//	env.<profile>.yaml, .yml or .json        values of the profile, which has to exist
+//	.env                                     local values in dotenv format
+//	.env.<profile>                           local values of the profile in dotenv format
+//	OS variables with the prefix             e.g. GODRIVER_ENV_host=localhost for host
+//
+// All files are looked up in Dir. Except for the profile file, they are optional.
+type EnvLoader struct {
+	// Dir contains the env files. It defaults to the working directory.
+	Dir string
+	// Profile selects the profile files. It defaults to the OS variable GODRIVER_PROFILE.
+	Profile string
+	// Prefix selects the OS variables. It defaults to DefaultEnvPrefix.
+	Prefix string
+	// Environ returns the OS variables. It defaults to os.Environ.
+	Environ func() []string
+}
+
+// LoadEnv loads the env of the profile from the files in dir and the OS variables,
+// as described by EnvLoader.
+func LoadEnv(dir, profile string) (map[string]string, error) {
+	loader := &EnvLoader{Dir: dir, Profile: profile}
+	return loader.Load()
+}
+
+// Load merges the sources of the env. The name of the profile is stored
+// under ProfileKey, if the sources do not contain the key.
+func (loader *EnvLoader) Load() (map[string]string, error) {
+	environ := loader.Environ
+	if environ == nil {
+		environ = os.Environ
+	}
+	osEnv := map[string]string{}
+	for _, entry := range environ() {
+		if i := strings.Index(entry, "="); i > 0 {
+			osEnv[entry[:i]] = entry[i+1:]
+		}
+	}
+	profile := loader.Profile
+	if profile == "" {
+		profile = osEnv[ProfileVariable]
+	}
+	prefix := loader.Prefix
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+
+	env := map[string]string{}
+	if _, err := loader.mergeProfileFile(env, "env"); err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		found, err := loader.mergeProfileFile(env, "env."+profile)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("no env file for profile %q in %v", profile, loader.dir())
+		}
+	}
+	if err := loader.mergeDotEnv(env, ".env"); err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		if err := loader.mergeDotEnv(env, ".env."+profile); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range osEnv {
+		if strings.HasPrefix(k, prefix) && len(k) > len(prefix) {
+			env[k[len(prefix):]] = v
+		}
+	}
+	if _, exists := env[ProfileKey]; !exists && profile != "" {
+		env[ProfileKey] = profile
+	}
+	return env, nil
+}
+
+func (loader *EnvLoader) dir() string {
+	if loader.Dir == "" {
+		return "."
+	}
+	return loader.Dir
+}
+
+// mergeProfileFile merges the first existing file of the name with a YAML or JSON extension.
+func (loader *EnvLoader) mergeProfileFile(env map[string]string, name string) (bool, error) {
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		path := filepath.Join(loader.dir(), name+ext)
+		b, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		values, err := parseEnvProfile(b, ext == ".json")
+		if err != nil {
+			return false, fmt.Errorf("%v: %v", path, err)
+		}
+		for k, v := range values {
+			env[k] = v
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (loader *EnvLoader) mergeDotEnv(env map[string]string, name string) error {
+	path := filepath.Join(loader.dir(), name)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	values, err := ParseDotEnv(f)
+	if err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	for k, v := range values {
+		env[k] = v
+	}
+	return nil
+}
+
+// parseEnvProfile reads a flat YAML or JSON object. Numbers and booleans are
+// converted to strings, nested values are not allowed.
+func parseEnvProfile(b []byte, isJSON bool) (map[string]string, error) {
+	values := map[string]interface{}{}
+	if isJSON {
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.UseNumber()
+		if err := decoder.Decode(&values); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string, len(values))
+	for k, v := range values {
+		switch v.(type) {
+		case map[interface{}]interface{}, map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("value of %q is not a scalar", k)
+		case nil:
+			env[k] = ""
+		default:
+			env[k] = fmt.Sprint(v)
+		}
+	}
+	return env, nil
+}
+
+// ParseDotEnv reads variables in the dotenv format:
+//
+//	# comment
+//	HOST=localhost
+//	export PORT=8080
+//	GREETING="hello\nworld"   # double quotes support escapes
+//	RAW='no $escapes\n'
+func ParseDotEnv(r io.Reader) (map[string]string, error) {
+	env := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		i := strings.Index(text, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %v: expected KEY=VALUE", line)
+		}
+		key := strings.TrimSpace(text[:i])
+		value, err := parseDotEnvValue(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+		env[key] = value
+	}
+	return env, scanner.Err()
+}
+
+func parseDotEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch value[0] {
+	case '"':
+		end := closingQuote(value)
+		if end < 0 {
+			return "", fmt.Errorf("missing closing quote")
+		}
+		return strconv.Unquote(value[:end+1])
+	case '\'':
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("missing closing quote")
+		}
+		return value[1 : end+1], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// closingQuote returns the index of the double quote, which closes the value, or -1.
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
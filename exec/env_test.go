@@ -0,0 +1,114 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeEnvFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "godriver-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_Env_Precedence(t *testing.T) {
+	a := assert.New(t)
+	dir := writeEnvFiles(t, map[string]string{
+		"env.yaml":         "host: common\nport: 8080\ntimeout: 1s\nuser: common\npassword: common\n",
+		"env.staging.json": `{"host": "staging", "port": 443, "tls": true, "user": "staging", "password": "staging"}`,
+		".env":             "user=local\npassword=local\n",
+		".env.staging":     "password=local-staging\n",
+	})
+	defer os.RemoveAll(dir)
+
+	loader := &EnvLoader{
+		Dir:     dir,
+		Environ: func() []string { return []string{"GODRIVER_PROFILE=staging", "GODRIVER_ENV_timeout=5s", "HOME=/root"} },
+	}
+	env, err := loader.Load()
+	a.NoError(err)
+	a.Equal(map[string]string{
+		"host":     "staging",
+		"port":     "443",
+		"tls":      "true",
+		"timeout":  "5s",
+		"user":     "local",
+		"password": "local-staging",
+		"profile":  "staging",
+	}, env)
+
+	loader.Profile = "prod"
+	_, err = loader.Load()
+	a.Error(err)
+	a.Contains(err.Error(), `no env file for profile "prod"`)
+
+	env, err = (&EnvLoader{Dir: dir, Environ: func() []string { return nil }}).Load()
+	a.NoError(err)
+	a.Equal("common", env["host"])
+	a.Equal("local", env["user"])
+	a.NotContains(env, "profile")
+}
+
+func Test_Env_InvalidProfile(t *testing.T) {
+	a := assert.New(t)
+	dir := writeEnvFiles(t, map[string]string{
+		"env.yml": "db:\n  host: localhost\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := LoadEnv(dir, "")
+	a.Error(err)
+	a.Contains(err.Error(), `value of "db" is not a scalar`)
+}
+
+func Test_Env_ParseDotEnv(t *testing.T) {
+	a := assert.New(t)
+
+	env, err := ParseDotEnv(strings.NewReader(`
+# comment
+HOST=localhost
+export PORT = 8080
+EMPTY=
+GREETING="hello\nworld" # comment
+QUOTED="say \"hi\""
+RAW='no\nescape'
+URL=http://host/#anchor # comment
+`))
+	a.NoError(err)
+	a.Equal(map[string]string{
+		"HOST":     "localhost",
+		"PORT":     "8080",
+		"EMPTY":    "",
+		"GREETING": "hello\nworld",
+		"QUOTED":   `say "hi"`,
+		"RAW":      `no\nescape`,
+		"URL":      "http://host/#anchor",
+	}, env)
+
+	_, err = ParseDotEnv(strings.NewReader("HOST\n"))
+	a.EqualError(err, "line 1: expected KEY=VALUE")
+	_, err = ParseDotEnv(strings.NewReader("\nA=\"open\n"))
+	a.EqualError(err, "line 2: missing closing quote")
+}
+
+func Test_Context_WithEnv(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewContext(map[string]string{"host": "default", "port": "80"})
+
+	derived := cntx.WithEnv(map[string]string{"host": "staging"})
+
+	a.Equal(map[string]string{"host": "staging", "port": "80"}, derived.Env())
+	a.Equal("default", cntx.Env()["host"])
+}
@@ -10,3 +10,23 @@ type Exec interface {
 	// String returns the description for the step
 	String(cntx Context) string
 }
+
+// Named is implemented by steps, which have a name independent
+// of the test data. The name is used to aggregate the executions of a step.
+type Named interface {
+	Name() string
+}
+
+// Validator is implemented by steps, which can detect configuration errors
+// like invalid templates before their execution.
+type Validator interface {
+	Validate() error
+}
+
+// Validate validates the step, if it implements Validator.
+func Validate(step Exec) error {
+	if validator, ok := step.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
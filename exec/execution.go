@@ -1,29 +1,67 @@
 package exec
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 )
 
 type Execution struct {
-	start    time.Time
-	end      time.Time
-	jobTitle string
-	err      error
-	context  Context
+	start     time.Time
+	end       time.Time
+	name      string
+	jobTitle  string
+	err       error
+	context   Context
+	steps     []*Execution
+	scenario  string
+	testGroup string
+	// trace context according to W3C Trace Context
+	traceId      [16]byte
+	spanId       [8]byte
+	parentSpanId [8]byte
 }
 
+// StartExecution starts the execution and binds the supplied context to it.
+// If the context was already bound to an execution, the new execution
+// is registered as a step of that one and becomes a child span in its trace.
+// Otherwise, the execution starts a new trace.
+// Secret values of the context are redacted from the job title.
 func StartExecution(jobTitle string, context *Context) *Execution {
-	return &Execution{
+	jobTitle = Redact(*context, jobTitle)
+	execution := &Execution{
 		start:    time.Now(),
+		name:     jobTitle,
 		jobTitle: jobTitle,
-		context:  *context,
 	}
+	rand.Read(execution.spanId[:])
+	if parent := executionOf(*context); parent != nil {
+		parent.steps = append(parent.steps, execution)
+		execution.traceId = parent.traceId
+		execution.parentSpanId = parent.spanId
+	} else {
+		rand.Read(execution.traceId[:])
+	}
+	*context = withExecution(*context, execution)
+	execution.context = *context
+	return execution
+}
+
+// startExecutionOf starts the execution of the supplied step
+// and names it by the step name, if the step has one.
+func startExecutionOf(step Exec, context *Context) *Execution {
+	execution := StartExecution(step.String(*context), context)
+	if named, ok := step.(Named); ok {
+		execution.name = named.Name()
+	}
+	return execution
 }
 
+// End ends the execution with the supplied error, where the secret values of the context are redacted.
 func (execution *Execution) End(err error) {
 	execution.end = time.Now()
-	execution.err = err
+	execution.err = redactError(execution.context, err)
 }
 
 func (execution *Execution) Duration() time.Duration {
@@ -34,6 +72,81 @@ func (execution *Execution) Error() error {
 	return execution.err
 }
 
+// StartTime returns the time, the execution was started.
+func (execution *Execution) StartTime() time.Time {
+	return execution.start
+}
+
+// EndTime returns the time, the execution was ended.
+func (execution *Execution) EndTime() time.Time {
+	return execution.end
+}
+
+// Name returns the name of the executed step, which does not
+// depend on the test data. It is used to aggregate executions.
+func (execution *Execution) Name() string {
+	return execution.name
+}
+
+// JobTitle returns the description of the execution.
+func (execution *Execution) JobTitle() string {
+	return execution.jobTitle
+}
+
+// Context returns the context, the execution was done with.
+func (execution *Execution) Context() Context {
+	return execution.context
+}
+
+// Steps returns the executions of the sub steps in the order of their execution.
+func (execution *Execution) Steps() []*Execution {
+	return execution.steps
+}
+
+// Scenario returns the name of the test scenario, the execution belongs to.
+func (execution *Execution) Scenario() string {
+	return execution.scenario
+}
+
+// TestGroup returns the test group of the scenario, the execution belongs to.
+func (execution *Execution) TestGroup() string {
+	return execution.testGroup
+}
+
+// TraceId returns the hex encoded id of the trace, the execution belongs to.
+func (execution *Execution) TraceId() string {
+	return hex.EncodeToString(execution.traceId[:])
+}
+
+// SpanId returns the hex encoded span id of the execution.
+func (execution *Execution) SpanId() string {
+	return hex.EncodeToString(execution.spanId[:])
+}
+
+// ParentSpanId returns the hex encoded span id of the parent execution,
+// or the empty string for a top level execution.
+func (execution *Execution) ParentSpanId() string {
+	if execution.parentSpanId == [8]byte{} {
+		return ""
+	}
+	return hex.EncodeToString(execution.parentSpanId[:])
+}
+
+// TraceParent returns the value of the W3C traceparent header,
+// which propagates the execution as parent span.
+func (execution *Execution) TraceParent() string {
+	return "00-" + execution.TraceId() + "-" + execution.SpanId() + "-01"
+}
+
+// setScenario assigns the execution and its steps to the scenario.
+func (execution *Execution) setScenario(scenario, testGroup string) {
+	execution.scenario = scenario
+	execution.testGroup = testGroup
+	for _, step := range execution.steps {
+		step.setScenario(scenario, testGroup)
+	}
+}
+
 func (execution *Execution) String() string {
 	if execution.err == nil {
 		return fmt.Sprintf("%v %v %v", execution.Duration(), execution.jobTitle, execution.context.CorrelationId())
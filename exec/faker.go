@@ -0,0 +1,339 @@
+package exec
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeKind is a kind of fake data, which a Faker generates.
+type FakeKind string
+
+const (
+	FakeFirstName  FakeKind = "firstName"
+	FakeLastName   FakeKind = "lastName"
+	FakeName       FakeKind = "name"
+	FakeUsername   FakeKind = "username"
+	FakeEmail      FakeKind = "email"
+	FakePhone      FakeKind = "phone"
+	FakeStreet     FakeKind = "street"
+	FakeCity       FakeKind = "city"
+	FakeZipCode    FakeKind = "zipCode"
+	FakeAddress    FakeKind = "address"
+	FakeIBAN       FakeKind = "iban"
+	FakeDate       FakeKind = "date"
+	FakeWord       FakeKind = "word"
+	FakeSentence   FakeKind = "sentence"
+	FakeParagraph  FakeKind = "paragraph"
+	FakeCreditCard FakeKind = "creditCard"
+)
+
+// fakerLocale contains the data of a language and country.
+type fakerLocale struct {
+	firstNames  []string
+	lastNames   []string
+	streets     []string
+	cities      []string
+	phone       func(random *rand.Rand) string
+	zipCode     func(random *rand.Rand) string
+	iban        func(random *rand.Rand) string
+	street      func(street string, number int) string
+	address     func(street, zipCode, city string) string
+	dateLayout  string
+	emailDomain []string
+}
+
+var fakerLocales = map[string]*fakerLocale{
+	"en": {
+		firstNames: []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth",
+			"David", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Charles", "Karen"},
+		lastNames: []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Miller", "Davis", "Wilson", "Anderson", "Taylor",
+			"Thomas", "Moore", "Jackson", "Martin", "Lee", "Thompson", "White", "Harris", "Clark", "Lewis"},
+		streets: []string{"Main Street", "Oak Avenue", "Maple Street", "Cedar Lane", "Park Road", "Pine Street", "Elm Street",
+			"Washington Avenue", "Lake Drive", "Hill Road", "Church Street", "High Street"},
+		cities: []string{"Springfield", "Riverside", "Franklin", "Greenville", "Bristol", "Clinton", "Fairview", "Salem",
+			"Madison", "Georgetown", "Arlington", "Ashland"},
+		phone: func(random *rand.Rand) string {
+			// 555-0100 to 555-0199 are reserved for fictional use
+			return fmt.Sprintf("+1 %03d-555-01%02d", 200+random.Intn(800), random.Intn(100))
+		},
+		zipCode: func(random *rand.Rand) string {
+			return fmt.Sprintf("%05d", 10000+random.Intn(90000))
+		},
+		iban: func(random *rand.Rand) string {
+			return ibanWithChecksum("GB", randomLetters(random, 4)+randomDigits(random, 14))
+		},
+		street: func(street string, number int) string {
+			return strconv.Itoa(number) + " " + street
+		},
+		address: func(street, zipCode, city string) string {
+			return street + ", " + city + " " + zipCode
+		},
+		dateLayout:  "01/02/2006",
+		emailDomain: []string{"example.com", "example.org", "example.net"},
+	},
+	"de": {
+		firstNames: []string{"Maximilian", "Sophie", "Alexander", "Marie", "Paul", "Maria", "Leon", "Sophia", "Lukas", "Anna",
+			"Jonas", "Emma", "Felix", "Hannah", "Tim", "Lena", "Jürgen", "Jörg", "Björn", "Käthe"},
+		lastNames: []string{"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker", "Schulz", "Hoffmann",
+			"Schäfer", "Koch", "Bauer", "Richter", "Klein", "Wolf", "Schröder", "Neumann", "Schwarz", "Zimmermann"},
+		streets: []string{"Hauptstraße", "Schulstraße", "Gartenstraße", "Bahnhofstraße", "Dorfstraße", "Bergstraße", "Birkenweg",
+			"Lindenstraße", "Kirchstraße", "Waldstraße", "Ringstraße", "Schillerstraße"},
+		cities: []string{"Berlin", "Hamburg", "München", "Köln", "Frankfurt am Main", "Stuttgart", "Düsseldorf", "Leipzig",
+			"Dortmund", "Essen", "Bremen", "Dresden"},
+		phone: func(random *rand.Rand) string {
+			return fmt.Sprintf("+49 %d %d", 30+random.Intn(60), 1000000+random.Intn(9000000))
+		},
+		zipCode: func(random *rand.Rand) string {
+			return fmt.Sprintf("%05d", 1067+random.Intn(98933))
+		},
+		iban: func(random *rand.Rand) string {
+			return ibanWithChecksum("DE", randomDigits(random, 18))
+		},
+		street: func(street string, number int) string {
+			return street + " " + strconv.Itoa(number)
+		},
+		address: func(street, zipCode, city string) string {
+			return street + ", " + zipCode + " " + city
+		},
+		dateLayout:  "02.01.2006",
+		emailDomain: []string{"example.de", "example.com", "example.org"},
+	},
+}
+
+var loremWords = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do",
+	"eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim", "ad", "minim", "veniam",
+	"quis", "nostrud", "exercitation", "ullamco", "laboris", "nisi", "aliquip", "ex", "ea", "commodo", "consequat"}
+
+// testCreditCards are the numbers, which the payment providers publish for tests.
+var testCreditCards = []string{"4111111111111111", "4012888888881881", "5555555555554444", "5105105105105100",
+	"378282246310005", "371449635398431", "6011111111111117", "3530111333300000"}
+
+var umlautReplacer = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue")
+
+// Faker generates realistic fake data for a locale.
+// A Faker with the same locale and seed generates the same data.
+// It is safe for concurrent use.
+type Faker struct {
+	locale *fakerLocale
+	seed   int64
+	mutex  sync.Mutex
+	random *rand.Rand
+}
+
+// NewFaker creates a faker for the locale, which is one of FakerLocales.
+func NewFaker(locale string, seed int64) (*Faker, error) {
+	l, exists := fakerLocales[locale]
+	if !exists {
+		return nil, fmt.Errorf("unknown faker locale %q, expected one of %v", locale, FakerLocales())
+	}
+	return &Faker{
+		locale: l,
+		seed:   seed,
+		random: rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// FakerLocales returns the names of the supported locales.
+func FakerLocales() []string {
+	names := make([]string, 0, len(fakerLocales))
+	for name := range fakerLocales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fake generates a value of the supplied kind.
+func (faker *Faker) Fake(kind FakeKind) (string, error) {
+	f, exists := fakeFuncs[kind]
+	if !exists {
+		return "", fmt.Errorf("unknown fake kind %q", kind)
+	}
+	faker.mutex.Lock()
+	defer faker.mutex.Unlock()
+	return f(faker), nil
+}
+
+var fakeFuncs = map[FakeKind]func(faker *Faker) string{
+	FakeFirstName:  (*Faker).firstName,
+	FakeLastName:   (*Faker).lastName,
+	FakeName:       (*Faker).name,
+	FakeUsername:   (*Faker).username,
+	FakeEmail:      (*Faker).email,
+	FakePhone:      func(faker *Faker) string { return faker.locale.phone(faker.random) },
+	FakeStreet:     (*Faker).street,
+	FakeCity:       func(faker *Faker) string { return faker.pick(faker.locale.cities) },
+	FakeZipCode:    func(faker *Faker) string { return faker.locale.zipCode(faker.random) },
+	FakeAddress:    (*Faker).address,
+	FakeIBAN:       func(faker *Faker) string { return faker.locale.iban(faker.random) },
+	FakeDate:       func(faker *Faker) string { return faker.date().Format(faker.locale.dateLayout) },
+	FakeWord:       func(faker *Faker) string { return faker.pick(loremWords) },
+	FakeSentence:   (*Faker).sentence,
+	FakeParagraph:  (*Faker).paragraph,
+	FakeCreditCard: func(faker *Faker) string { return faker.pick(testCreditCards) },
+}
+
+func (faker *Faker) pick(values []string) string {
+	return values[faker.random.Intn(len(values))]
+}
+
+func (faker *Faker) firstName() string {
+	return faker.pick(faker.locale.firstNames)
+}
+
+func (faker *Faker) lastName() string {
+	return faker.pick(faker.locale.lastNames)
+}
+
+func (faker *Faker) name() string {
+	return faker.firstName() + " " + faker.lastName()
+}
+
+func (faker *Faker) username() string {
+	name := faker.firstName() + "." + faker.lastName()
+	return strings.ToLower(umlautReplacer.Replace(name)) + strconv.Itoa(faker.random.Intn(100))
+}
+
+func (faker *Faker) email() string {
+	return faker.username() + "@" + faker.pick(faker.locale.emailDomain)
+}
+
+func (faker *Faker) street() string {
+	return faker.locale.street(faker.pick(faker.locale.streets), 1+faker.random.Intn(200))
+}
+
+func (faker *Faker) address() string {
+	return faker.locale.address(faker.street(), faker.locale.zipCode(faker.random), faker.pick(faker.locale.cities))
+}
+
+// date returns a date of birth of an adult.
+func (faker *Faker) date() time.Time {
+	from := time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)
+	return from.AddDate(0, 0, faker.random.Intn(55*365))
+}
+
+func (faker *Faker) sentence() string {
+	words := make([]string, 4+faker.random.Intn(8))
+	for i := range words {
+		words[i] = faker.pick(loremWords)
+	}
+	s := strings.Join(words, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+func (faker *Faker) paragraph() string {
+	sentences := make([]string, 3+faker.random.Intn(4))
+	for i := range sentences {
+		sentences[i] = faker.sentence()
+	}
+	return strings.Join(sentences, " ")
+}
+
+// TestData returns a closure for Context.Populate, which generates test data
+// with the supplied keys and kinds, e.g. {"user": FakeUsername, "mail": FakeEmail}.
+// The data of each test number only depends on the seed of the faker,
+// not on the order of the calls. An unknown kind is returned as error.
+func (faker *Faker) TestData(fields map[string]FakeKind) (func(testNumber int) map[string]string, error) {
+	keys := make([]string, 0, len(fields))
+	for key, kind := range fields {
+		if _, exists := fakeFuncs[kind]; !exists {
+			return nil, fmt.Errorf("unknown fake kind %q for key %q", kind, key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return func(testNumber int) map[string]string {
+		testFaker := &Faker{
+			locale: faker.locale,
+			random: rand.New(rand.NewSource(faker.seed*1000003 + int64(testNumber))),
+		}
+		data := make(map[string]string, len(keys))
+		for _, key := range keys {
+			data[key] = fakeFuncs[fields[key]](testFaker)
+		}
+		return data
+	}, nil
+}
+
+// MustTestData is like TestData, but panics for unknown kinds.
+func (faker *Faker) MustTestData(fields map[string]FakeKind) func(testNumber int) map[string]string {
+	testData, err := faker.TestData(fields)
+	if err != nil {
+		panic(err)
+	}
+	return testData
+}
+
+func randomDigits(random *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + random.Intn(10))
+	}
+	return string(b)
+}
+
+func randomLetters(random *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('A' + random.Intn(26))
+	}
+	return string(b)
+}
+
+// ibanWithChecksum calculates the check digits of the IBAN by ISO 7064 mod 97-10.
+func ibanWithChecksum(country, bban string) string {
+	numeric := strings.Builder{}
+	for _, c := range bban + country + "00" {
+		if c >= 'A' && c <= 'Z' {
+			numeric.WriteString(strconv.Itoa(int(c-'A') + 10))
+		} else {
+			numeric.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(numeric.String(), 10)
+	check := 98 - new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("%v%02d%v", country, check, bban)
+}
+
+// templateFakers are the fakers of the fake template function, by locale.
+var templateFakers = struct {
+	sync.Mutex
+	seed   int64
+	fakers map[string]*Faker
+}{seed: time.Now().UnixNano(), fakers: map[string]*Faker{}}
+
+// SeedTemplateFakers makes the values of the fake template function reproducible.
+func SeedTemplateFakers(seed int64) {
+	templateFakers.Lock()
+	defer templateFakers.Unlock()
+	templateFakers.seed = seed
+	templateFakers.fakers = map[string]*Faker{}
+}
+
+// fakeTemplateFunc generates a value for the fake template function,
+// e.g. {{fake "email"}} or {{fake "address" "de"}}. The locale defaults to en.
+func fakeTemplateFunc(kind string, locale ...string) (string, error) {
+	localeName := "en"
+	if len(locale) > 0 {
+		localeName = locale[0]
+	}
+	templateFakers.Lock()
+	faker, exists := templateFakers.fakers[localeName]
+	if !exists {
+		var err error
+		faker, err = NewFaker(localeName, templateFakers.seed)
+		if err != nil {
+			templateFakers.Unlock()
+			return "", err
+		}
+		templateFakers.fakers[localeName] = faker
+	}
+	templateFakers.Unlock()
+	return faker.Fake(FakeKind(kind))
+}
@@ -0,0 +1,137 @@
+package exec
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Faker_AllKinds(t *testing.T) {
+	a := assert.New(t)
+
+	for _, locale := range FakerLocales() {
+		faker, err := NewFaker(locale, 1)
+		a.NoError(err)
+		for kind := range fakeFuncs {
+			value, err := faker.Fake(kind)
+			a.NoError(err)
+			a.NotEmpty(value, "%v %v", locale, kind)
+		}
+	}
+	a.Equal([]string{"de", "en"}, FakerLocales())
+}
+
+func Test_Faker_Seeded(t *testing.T) {
+	a := assert.New(t)
+	f1, _ := NewFaker("de", 42)
+	f2, _ := NewFaker("de", 42)
+
+	for i := 0; i < 10; i++ {
+		v1, _ := f1.Fake(FakeAddress)
+		v2, _ := f2.Fake(FakeAddress)
+		a.Equal(v1, v2)
+	}
+}
+
+func Test_Faker_Formats(t *testing.T) {
+	a := assert.New(t)
+	en, _ := NewFaker("en", 7)
+	de, _ := NewFaker("de", 7)
+
+	for i := 0; i < 20; i++ {
+		email, _ := de.Fake(FakeEmail)
+		a.Regexp(regexp.MustCompile(`^[a-z]+\.[a-z]+[0-9]*@example\.(de|com|org)$`), email)
+
+		iban, _ := de.Fake(FakeIBAN)
+		a.Regexp(regexp.MustCompile(`^DE[0-9]{20}$`), iban)
+		a.True(validIBAN(iban), iban)
+		iban, _ = en.Fake(FakeIBAN)
+		a.Regexp(regexp.MustCompile(`^GB[0-9]{2}[A-Z]{4}[0-9]{14}$`), iban)
+		a.True(validIBAN(iban), iban)
+
+		card, _ := en.Fake(FakeCreditCard)
+		a.True(luhnValid(card), card)
+
+		date, _ := de.Fake(FakeDate)
+		a.Regexp(regexp.MustCompile(`^[0-9]{2}\.[0-9]{2}\.(19[5-9][0-9]|200[0-4])$`), date)
+
+		address, _ := de.Fake(FakeAddress)
+		a.Regexp(regexp.MustCompile(`^\S.* [0-9]+, [0-9]{5} .+$`), address)
+	}
+
+	_, err := en.Fake("unknown")
+	a.EqualError(err, `unknown fake kind "unknown"`)
+	_, err = NewFaker("fr", 1)
+	a.Error(err)
+}
+
+func Test_Faker_TestData(t *testing.T) {
+	a := assert.New(t)
+	faker, _ := NewFaker("en", 3)
+	testData, err := faker.TestData(map[string]FakeKind{"user": FakeUsername, "mail": FakeEmail})
+	a.NoError(err)
+
+	first := testData(1)
+	a.Equal(2, len(first))
+	testData(2)
+	a.Equal(first, testData(1))
+
+	users := map[string]bool{}
+	for cntx := range NewDefaultContext().Populate(5, testData) {
+		users[cntx.Test()["user"]] = true
+	}
+	a.True(len(users) > 1)
+
+	_, err = faker.TestData(map[string]FakeKind{"x": "unknown"})
+	a.EqualError(err, `unknown fake kind "unknown" for key "x"`)
+	a.Panics(func() {
+		faker.MustTestData(map[string]FakeKind{"x": "unknown"})
+	})
+	a.NotNil(faker.MustTestData(map[string]FakeKind{"user": FakeUsername}))
+}
+
+func Test_Faker_TemplateFunc(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewDefaultContext()
+
+	SeedTemplateFakers(5)
+	first := cntx.ExpandVarsNoError(`{{fake "name"}} {{fake "city" "de"}}`)
+	SeedTemplateFakers(5)
+	a.Equal(first, cntx.ExpandVarsNoError(`{{fake "name"}} {{fake "city" "de"}}`))
+
+	_, err := cntx.ExpandVars(`{{fake "name" "xx"}}`)
+	a.Error(err)
+}
+
+func validIBAN(iban string) bool {
+	numeric := ""
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			numeric += strconv.Itoa(int(c-'A') + 10)
+		} else {
+			numeric += string(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(numeric, 10)
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func luhnValid(number string) bool {
+	sum := 0
+	digits := strings.Split(number, "")
+	for i := range digits {
+		d, _ := strconv.Atoi(digits[len(digits)-1-i])
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
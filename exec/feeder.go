@@ -0,0 +1,421 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FeedFormat is the file format of test data feeds.
+type FeedFormat string
+
+const (
+	// FeedCSV contains a header row with the keys and one row per test.
+	FeedCSV FeedFormat = "csv"
+	// FeedJSON contains an array of objects.
+	FeedJSON FeedFormat = "json"
+	// FeedJSONLines contains one object per line.
+	FeedJSONLines FeedFormat = "jsonl"
+)
+
+// FeedStrategy defines the order, in which a Feeder hands out its rows.
+type FeedStrategy int
+
+const (
+	// FeedSequential hands out the rows in their order.
+	FeedSequential FeedStrategy = iota
+	// FeedRandom hands out the rows in a random order, each row once per pass.
+	FeedRandom
+	// FeedCircular hands out the rows in their order and starts over after the last row.
+	// It is never exhausted.
+	FeedCircular
+	// FeedUniquePerWorker partitions the rows into disjoint blocks, one per worker,
+	// and feeds each worker from its own block in the order of the rows.
+	// The row is taken, when the worker starts the test, so that combined with FeedRecycle
+	// a row is never used by two workers at the same time. Without recycling,
+	// the feed ends, as soon as the first worker exhausted its block.
+	FeedUniquePerWorker
+)
+
+// FeedExhaustion defines what happens, when all rows were handed out.
+type FeedExhaustion int
+
+const (
+	// FeedStop ends the feed.
+	FeedStop FeedExhaustion = iota
+	// FeedRecycle starts over with the first row, or a new random order.
+	FeedRecycle
+	// FeedError ends the feed with an error, e.g. if each row may only be used once.
+	FeedError
+)
+
+// ErrFeedExhausted is returned by Feeder.Next, if the feed was stopped after its last row.
+var ErrFeedExhausted = errors.New("feed exhausted")
+
+// Feeder hands out rows of test data according to its strategy.
+// It is safe for concurrent use.
+type Feeder struct {
+	rows       []map[string]string
+	strategy   FeedStrategy
+	workers    int
+	exhaustion FeedExhaustion
+
+	mutex  sync.Mutex
+	random *rand.Rand
+	blocks []*feedBlock
+	err    error
+	// ended is set, if a block of FeedUniquePerWorker is exhausted
+	ended bool
+}
+
+type feedBlock struct {
+	order    []int
+	position int
+}
+
+// NewFeeder creates a sequential feeder, which stops after the last row.
+func NewFeeder(rows []map[string]string) *Feeder {
+	return &Feeder{
+		rows:    rows,
+		workers: 1,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// LoadFeeder reads the rows from the file at path and creates a feeder with them.
+// The format is detected by FeedFormatOf.
+func LoadFeeder(path string) (*Feeder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, err := ReadFeed(f, FeedFormatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return NewFeeder(rows), nil
+}
+
+// WithStrategy sets the order of the rows. The default is FeedSequential.
+func (feeder *Feeder) WithStrategy(strategy FeedStrategy) *Feeder {
+	feeder.mutex.Lock()
+	defer feeder.mutex.Unlock()
+	feeder.strategy = strategy
+	feeder.blocks = nil
+	return feeder
+}
+
+// WithWorkers sets the number of blocks for FeedUniquePerWorker,
+// which should be the concurrency of the scenario. If there are more workers
+// than blocks, worker i uses block i mod workers.
+func (feeder *Feeder) WithWorkers(workers int) *Feeder {
+	feeder.mutex.Lock()
+	defer feeder.mutex.Unlock()
+	if workers < 1 {
+		workers = 1
+	}
+	feeder.workers = workers
+	feeder.blocks = nil
+	feeder.ended = false
+	return feeder
+}
+
+// WithExhaustion sets the behaviour after the last row. The default is FeedStop.
+func (feeder *Feeder) WithExhaustion(exhaustion FeedExhaustion) *Feeder {
+	feeder.mutex.Lock()
+	defer feeder.mutex.Unlock()
+	feeder.exhaustion = exhaustion
+	return feeder
+}
+
+// WithSeed makes the random order reproducible.
+func (feeder *Feeder) WithSeed(seed int64) *Feeder {
+	feeder.mutex.Lock()
+	defer feeder.mutex.Unlock()
+	feeder.random = rand.New(rand.NewSource(seed))
+	feeder.blocks = nil
+	return feeder
+}
+
+// Len returns the number of rows.
+func (feeder *Feeder) Len() int {
+	return len(feeder.rows)
+}
+
+// Next returns a copy of the next row. For FeedUniquePerWorker, it is the next row of the first worker.
+// If the feed is exhausted, it returns ErrFeedExhausted for FeedStop
+// and a descriptive error for FeedError.
+func (feeder *Feeder) Next() (map[string]string, error) {
+	return feeder.NextForWorker(0)
+}
+
+// NextForWorker returns a copy of the next row for the worker with the supplied index like Next.
+// For FeedUniquePerWorker, it is the next row of the block of the worker, otherwise the index is not used.
+func (feeder *Feeder) NextForWorker(worker int) (map[string]string, error) {
+	feeder.mutex.Lock()
+	defer feeder.mutex.Unlock()
+	if feeder.blocks == nil {
+		feeder.initBlocks()
+	}
+
+	block := feeder.blocks[worker%len(feeder.blocks)]
+	if block.position == len(block.order) {
+		if len(block.order) == 0 {
+			return nil, feeder.exhausted(block)
+		}
+		if feeder.strategy != FeedCircular && feeder.exhaustion != FeedRecycle {
+			return nil, feeder.exhausted(block)
+		}
+		block.position = 0
+		if feeder.strategy == FeedRandom {
+			feeder.shuffle(block.order)
+		}
+	}
+
+	row := feeder.rows[block.order[block.position]]
+	block.position++
+	return copyStringMap(row), nil
+}
+
+func (feeder *Feeder) exhausted(block *feedBlock) error {
+	feeder.ended = true
+	if feeder.exhaustion == FeedError {
+		return fmt.Errorf("feed exhausted after %v rows", len(block.order))
+	}
+	return ErrFeedExhausted
+}
+
+func (feeder *Feeder) initBlocks() {
+	count := 1
+	if feeder.strategy == FeedUniquePerWorker {
+		count = feeder.workers
+	}
+	feeder.ended = false
+	feeder.blocks = make([]*feedBlock, count)
+	for i := range feeder.blocks {
+		feeder.blocks[i] = &feedBlock{}
+	}
+	for i := range feeder.rows {
+		block := feeder.blocks[i%count]
+		block.order = append(block.order, i)
+	}
+	if feeder.strategy == FeedRandom {
+		feeder.shuffle(feeder.blocks[0].order)
+	}
+}
+
+func (feeder *Feeder) shuffle(order []int) {
+	feeder.random.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+}
+
+// Populate works like Context.Populate, but derives the contexts with the rows of the feeder.
+// For n <= 0, it feeds until the feed is exhausted.
+// If the feed ends with an error, the channel is closed and the error is returned by Err.
+//
+// For FeedUniquePerWorker, the contexts get their row, when a worker of a run starts the test,
+// and a worker stops, when its block is exhausted.
+func (feeder *Feeder) Populate(cntx Context, n int) chan Context {
+	resultChannel := make(chan Context)
+	go func() {
+		defer close(resultChannel)
+		currentContext := cntx
+		for i := cntx.TestNumber() + 1; n <= 0 || i <= n; i++ {
+			if next, ok := feeder.deferredContext(currentContext); ok {
+				if next == nil {
+					return
+				}
+				currentContext = next
+				resultChannel <- currentContext
+				continue
+			}
+			row, err := feeder.Next()
+			if err != nil {
+				if err != ErrFeedExhausted {
+					feeder.mutex.Lock()
+					feeder.err = err
+					feeder.mutex.Unlock()
+				}
+				return
+			}
+			currentContext = currentContext.Derive(row)
+			resultChannel <- currentContext
+		}
+	}()
+	return resultChannel
+}
+
+// deferredContext returns the next context without data for FeedUniquePerWorker, which is fed
+// by feedWorker, or nil, if the feed ended. It returns false, if the rows are assigned by Populate.
+func (feeder *Feeder) deferredContext(cntx Context) (Context, bool) {
+	feeder.mutex.Lock()
+	defer feeder.mutex.Unlock()
+	if feeder.strategy != FeedUniquePerWorker {
+		return nil, false
+	}
+	if feeder.ended {
+		return nil, true
+	}
+	next, ok := cntx.Derive(nil).(*ContextImpl)
+	if !ok {
+		return nil, false
+	}
+	next.feeder = feeder
+	return next, true
+}
+
+// feedWorker sets the test data of a context of a FeedUniquePerWorker feeder
+// to the next row of the block of its worker.
+func feedWorker(cntx Context) (Context, error) {
+	impl, ok := cntx.(*ContextImpl)
+	if !ok || impl.feeder == nil {
+		return cntx, nil
+	}
+	row, err := impl.feeder.NextForWorker(impl.worker)
+	if err != nil {
+		if err != ErrFeedExhausted {
+			impl.feeder.mutex.Lock()
+			impl.feeder.err = err
+			impl.feeder.mutex.Unlock()
+		}
+		return cntx, err
+	}
+	fed := *impl
+	fed.feeder = nil
+	fed.test = make(map[string]string, len(impl.test)+len(row))
+	for k, v := range impl.test {
+		fed.test[k] = v
+	}
+	for k, v := range row {
+		fed.test[k] = v
+	}
+	return &fed, nil
+}
+
+// Err returns the error, which ended the last Populate, if any.
+func (feeder *Feeder) Err() error {
+	feeder.mutex.Lock()
+	defer feeder.mutex.Unlock()
+	return feeder.err
+}
+
+// FeedFormatOf returns the format for a file name by its extension.
+// Files ending on .csv are CSV, on .jsonl or .ndjson JSON lines and all others JSON.
+func FeedFormatOf(path string) FeedFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FeedCSV
+	case ".jsonl", ".ndjson":
+		return FeedJSONLines
+	}
+	return FeedJSON
+}
+
+// ReadFeed reads rows of test data. Values of JSON objects, which are no strings,
+// are stored in their JSON encoding, e.g. 42 or {"a":1}.
+func ReadFeed(r io.Reader, format FeedFormat) ([]map[string]string, error) {
+	switch format {
+	case FeedCSV:
+		return readCSVFeed(r)
+	case FeedJSONLines:
+		return readJSONLinesFeed(r)
+	}
+	return readJSONFeed(r)
+}
+
+func readCSVFeed(r io.Reader) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	reader.FieldsPerRecord = len(header)
+	rows := []map[string]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, key := range header {
+			row[key] = record[i]
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readJSONFeed(r io.Reader) ([]map[string]string, error) {
+	objects := []map[string]json.RawMessage{}
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]string, len(objects))
+	for i, object := range objects {
+		row, err := feedRow(object)
+		if err != nil {
+			return nil, fmt.Errorf("entry %v: %v", i+1, err)
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+func readJSONLinesFeed(r io.Reader) ([]map[string]string, error) {
+	rows := []map[string]string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		object := map[string]json.RawMessage{}
+		if err := json.Unmarshal(b, &object); err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+		row, err := feedRow(object)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+func feedRow(object map[string]json.RawMessage) (map[string]string, error) {
+	row := make(map[string]string, len(object))
+	for key, raw := range object {
+		if len(raw) > 0 && raw[0] == '"' {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, err
+			}
+			row[key] = s
+			continue
+		}
+		compact := bytes.NewBuffer(nil)
+		if err := json.Compact(compact, raw); err != nil {
+			return nil, err
+		}
+		row[key] = compact.String()
+	}
+	return row, nil
+}
@@ -0,0 +1,207 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func feedRows(values ...string) []map[string]string {
+	rows := []map[string]string{}
+	for _, v := range values {
+		rows = append(rows, map[string]string{"user": v})
+	}
+	return rows
+}
+
+func nextUsers(feeder *Feeder, n int) ([]string, error) {
+	users := []string{}
+	for i := 1; i <= n; i++ {
+		row, err := feeder.Next()
+		if err != nil {
+			return users, err
+		}
+		users = append(users, row["user"])
+	}
+	return users, nil
+}
+
+func Test_Feeder_Strategies(t *testing.T) {
+	a := assert.New(t)
+
+	users, err := nextUsers(NewFeeder(feedRows("a", "b", "c")), 4)
+	a.Equal([]string{"a", "b", "c"}, users)
+	a.Equal(ErrFeedExhausted, err)
+
+	users, err = nextUsers(NewFeeder(feedRows("a", "b", "c")).WithExhaustion(FeedRecycle), 5)
+	a.NoError(err)
+	a.Equal([]string{"a", "b", "c", "a", "b"}, users)
+
+	users, err = nextUsers(NewFeeder(feedRows("a", "b")).WithStrategy(FeedCircular).WithExhaustion(FeedError), 5)
+	a.NoError(err)
+	a.Equal([]string{"a", "b", "a", "b", "a"}, users)
+
+	users, err = nextUsers(NewFeeder(feedRows("a", "b", "c")).WithExhaustion(FeedError), 4)
+	a.EqualError(err, "feed exhausted after 3 rows")
+
+	users, err = nextUsers(NewFeeder(feedRows("a", "b", "c", "d")).WithStrategy(FeedRandom).WithSeed(42), 4)
+	a.NoError(err)
+	a.ElementsMatch([]string{"a", "b", "c", "d"}, users)
+	again, _ := nextUsers(NewFeeder(feedRows("a", "b", "c", "d")).WithStrategy(FeedRandom).WithSeed(42), 4)
+	a.Equal(users, again)
+
+	perWorker := NewFeeder(feedRows("a", "b", "c", "d", "e")).WithStrategy(FeedUniquePerWorker).WithWorkers(2).WithExhaustion(FeedRecycle)
+	users = []string{}
+	for _, worker := range []int{1, 1, 1, 0, 0, 0, 0, 3} {
+		row, err := perWorker.NextForWorker(worker)
+		a.NoError(err)
+		users = append(users, row["user"])
+	}
+	a.Equal([]string{"b", "d", "b", "a", "c", "e", "a", "d"}, users)
+
+	users, err = nextUsers(NewFeeder(feedRows("a", "b", "c")).WithStrategy(FeedUniquePerWorker).WithWorkers(2), 3)
+	a.Equal([]string{"a", "c"}, users)
+	a.Equal(ErrFeedExhausted, err)
+
+	_, err = nextUsers(NewFeeder(feedRows()).WithStrategy(FeedCircular), 1)
+	a.Equal(ErrFeedExhausted, err)
+}
+
+func Test_Feeder_NextReturnsCopy(t *testing.T) {
+	a := assert.New(t)
+	feeder := NewFeeder(feedRows("a")).WithExhaustion(FeedRecycle)
+
+	row, _ := feeder.Next()
+	row["user"] = "changed"
+	row, _ = feeder.Next()
+	a.Equal("a", row["user"])
+}
+
+func Test_Feeder_Populate(t *testing.T) {
+	a := assert.New(t)
+
+	users := []string{}
+	for cntx := range NewFeeder(feedRows("a", "b", "c")).Populate(NewDefaultContext(), 0) {
+		users = append(users, cntx.Test()["user"]+string(rune('0'+cntx.TestNumber())))
+	}
+	a.Equal([]string{"a1", "b2", "c3"}, users)
+
+	count := 0
+	for range NewFeeder(feedRows("a")).WithExhaustion(FeedRecycle).Populate(NewDefaultContext(), 5) {
+		count++
+	}
+	a.Equal(5, count)
+
+	feeder := NewFeeder(feedRows("a")).WithExhaustion(FeedError)
+	count = 0
+	for range feeder.Populate(NewDefaultContext(), 5) {
+		count++
+	}
+	a.Equal(1, count)
+	a.EqualError(feeder.Err(), "feed exhausted after 1 rows")
+}
+
+func Test_Feeder_UniquePerWorker(t *testing.T) {
+	a := assert.New(t)
+
+	var mutex sync.Mutex
+	inUse := map[string]int{}
+	byWorker := map[int]map[string]bool{}
+	shared := false
+	spec := contextExec(func(cntx Context) error {
+		user := cntx.Test()["user"]
+		mutex.Lock()
+		inUse[user]++
+		shared = shared || inUse[user] > 1
+		if byWorker[cntx.(WorkerContext).Worker()] == nil {
+			byWorker[cntx.(WorkerContext).Worker()] = map[string]bool{}
+		}
+		byWorker[cntx.(WorkerContext).Worker()][user] = true
+		mutex.Unlock()
+		time.Sleep(time.Millisecond)
+		mutex.Lock()
+		inUse[user]--
+		mutex.Unlock()
+		return nil
+	})
+
+	feeder := NewFeeder(feedRows("a", "b", "c", "d", "e", "f")).WithStrategy(FeedUniquePerWorker).WithWorkers(3).WithExhaustion(FeedRecycle)
+	count := 0
+	for execution := range RunParallel(3, spec, feeder.Populate(NewDefaultContext(), 60)) {
+		a.NoError(execution.Error())
+		count++
+	}
+	a.Equal(60, count)
+	a.False(shared)
+	a.Equal(3, len(byWorker))
+	a.Equal(map[string]bool{"a": true, "d": true}, byWorker[0])
+	a.Equal(map[string]bool{"b": true, "e": true}, byWorker[1])
+	a.Equal(map[string]bool{"c": true, "f": true}, byWorker[2])
+
+	// without recycling, the feed ends after the first exhausted block
+	feeder = NewFeeder(feedRows("a", "b", "c", "d")).WithStrategy(FeedUniquePerWorker).WithWorkers(2)
+	seen := map[string]bool{}
+	for execution := range RunParallel(2, spec, feeder.Populate(NewDefaultContext(), 0)) {
+		a.NoError(execution.Error())
+		user := execution.Context().Test()["user"]
+		a.False(seen[user], user)
+		seen[user] = true
+	}
+	a.True(len(seen) >= 2)
+	a.NoError(feeder.Err())
+}
+
+func Test_Feeder_ReadFeed(t *testing.T) {
+	a := assert.New(t)
+	expected := []map[string]string{
+		{"user": "alice", "age": "42"},
+		{"user": "bob", "age": "7"},
+	}
+
+	rows, err := ReadFeed(strings.NewReader("user,age\nalice,42\nbob,7\n"), FeedCSV)
+	a.NoError(err)
+	a.Equal(expected, rows)
+
+	rows, err = ReadFeed(strings.NewReader(`[{"user": "alice", "age": 42}, {"user": "bob", "age": 7}]`), FeedJSON)
+	a.NoError(err)
+	a.Equal(expected, rows)
+
+	rows, err = ReadFeed(strings.NewReader("{\"user\": \"alice\", \"age\": 42}\n\n{\"user\": \"bob\", \"age\": 7}\n"), FeedJSONLines)
+	a.NoError(err)
+	a.Equal(expected, rows)
+
+	rows, err = ReadFeed(strings.NewReader(`[{"address": {"city": "Berlin", "zip": 10115}}]`), FeedJSON)
+	a.NoError(err)
+	a.Equal(`{"city":"Berlin","zip":10115}`, rows[0]["address"])
+
+	_, err = ReadFeed(strings.NewReader("user,age\nalice\n"), FeedCSV)
+	a.Error(err)
+	_, err = ReadFeed(strings.NewReader("{\"user\": \"alice\"}\n{broken\n"), FeedJSONLines)
+	a.Error(err)
+	a.Contains(err.Error(), "line 2")
+}
+
+func Test_Feeder_LoadFeeder(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "godriver-feeder")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "users.csv")
+	a.NoError(ioutil.WriteFile(path, []byte("user\nalice\nbob\n"), 0644))
+	feeder, err := LoadFeeder(path)
+	a.NoError(err)
+	a.Equal(2, feeder.Len())
+
+	a.Equal(FeedJSONLines, FeedFormatOf("users.ndjson"))
+	a.Equal(FeedJSON, FeedFormatOf("users.json"))
+
+	_, err = LoadFeeder(filepath.Join(dir, "missing.csv"))
+	a.Error(err)
+}
@@ -0,0 +1,73 @@
+package exec
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Filter selects scenarios of a repository. All criteria have to match,
+// empty criteria match all scenarios.
+type Filter struct {
+	// Group is a regular expression for the test group.
+	Group string
+	// Name is a regular expression for the scenario name.
+	Name string
+	// Tags are regular expressions, each of which has to match one of the tags.
+	Tags []string
+	// GroupExpression is a TagExpression over the test group, e.g. "api || web".
+	GroupExpression string
+	// TagExpression is a TagExpression over the tags, e.g. "smoke && !slow".
+	TagExpression string
+}
+
+// compiledFilter is a validated filter.
+type compiledFilter struct {
+	group           *regexp.Regexp
+	name            *regexp.Regexp
+	tags            []*regexp.Regexp
+	groupExpression *TagExpression
+	tagExpression   *TagExpression
+}
+
+func (filter Filter) compile() (*compiledFilter, error) {
+	compiled := &compiledFilter{}
+	var err error
+	if compiled.group, err = regexp.Compile(filter.Group); err != nil {
+		return nil, fmt.Errorf("invalid group: %v", err)
+	}
+	if compiled.name, err = regexp.Compile(filter.Name); err != nil {
+		return nil, fmt.Errorf("invalid name: %v", err)
+	}
+	for _, tag := range filter.Tags {
+		tagRegex, err := regexp.Compile(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag: %v", err)
+		}
+		compiled.tags = append(compiled.tags, tagRegex)
+	}
+	if compiled.groupExpression, err = ParseTagExpression(filter.GroupExpression); err != nil {
+		return nil, fmt.Errorf("group expression: %v", err)
+	}
+	if compiled.tagExpression, err = ParseTagExpression(filter.TagExpression); err != nil {
+		return nil, fmt.Errorf("tag expression: %v", err)
+	}
+	return compiled, nil
+}
+
+func (filter *compiledFilter) matches(t *repositoryEntry) bool {
+	if !filter.name.MatchString(t.testScenario.Name) || !filter.group.MatchString(t.testGroup) {
+		return false
+	}
+	for _, tagRegex := range filter.tags {
+		matchesOneTag := false
+		for _, tag := range t.tags {
+			if tagRegex.MatchString(tag) {
+				matchesOneTag = true
+			}
+		}
+		if !matchesOneTag {
+			return false
+		}
+	}
+	return filter.groupExpression.Matches(t.testGroup) && filter.tagExpression.Matches(t.tags...)
+}
@@ -0,0 +1,75 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFilterRepository() *Repository {
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("login", newMockExec("login"), newChannelFactory()), "api", 1, "smoke")
+	repo.MustAdd(NewTestScenario("orders", newMockExec("orders"), newChannelFactory()).DependsOn("login"), "api", 1, "smoke", "slow")
+	repo.MustAdd(NewTestScenario("search", newMockExec("search"), newChannelFactory()), "web", 1, "smoke", "prod-safe")
+	repo.MustAdd(NewTestScenario("import", newMockExec("import"), newChannelFactory()), "batch", 1, "prod-safe")
+	return repo
+}
+
+func Test_Filter_Select(t *testing.T) {
+	a := assert.New(t)
+	repo := newFilterRepository()
+
+	names := func(filter Filter) []string {
+		infos, err := repo.Select(filter)
+		a.NoError(err)
+		names := []string{}
+		for _, info := range infos {
+			names = append(names, info.Name)
+		}
+		return names
+	}
+
+	a.Equal([]string{"login", "orders", "search", "import"}, names(Filter{}))
+	a.Equal([]string{"login", "search"}, names(Filter{TagExpression: "smoke && !slow"}))
+	a.Equal([]string{"search", "import"}, names(Filter{TagExpression: "(api || web || prod-*) && prod-safe"}))
+	a.Equal([]string{"login", "search"}, names(Filter{GroupExpression: "!batch", TagExpression: "!slow"}))
+	a.Equal([]string{"search"}, names(Filter{Name: "^s", Tags: []string{"smoke"}, GroupExpression: "web || batch"}))
+
+	infos, err := repo.Select(Filter{TagExpression: "slow"})
+	a.NoError(err)
+	a.Equal(2, len(infos))
+	a.Equal("login", infos[0].Name)
+	a.True(infos[0].Dependency)
+	a.Equal("orders", infos[1].Name)
+	a.False(infos[1].Dependency)
+}
+
+func Test_Filter_Invalid(t *testing.T) {
+	a := assert.New(t)
+	repo := newFilterRepository()
+
+	_, err := repo.Select(Filter{TagExpression: "smoke &&"})
+	a.EqualError(err, `tag expression: invalid expression "smoke &&": unexpected end`)
+	_, err = repo.Select(Filter{GroupExpression: "(api"})
+	a.EqualError(err, `group expression: invalid expression "(api": missing )`)
+	_, err = repo.Select(Filter{Name: "("})
+	a.Error(err)
+	a.Contains(err.Error(), "invalid name: ")
+
+	mockResult = ""
+	_, err = repo.RunFiltered(Filter{Tags: []string{"["}})
+	a.Error(err)
+	a.Equal("", mockResult)
+}
+
+func Test_Filter_RunFiltered(t *testing.T) {
+	a := assert.New(t)
+	repo := newFilterRepository()
+
+	mockResult = ""
+	report, err := repo.RunFiltered(Filter{GroupExpression: "api", TagExpression: "slow"})
+	a.NoError(err)
+	a.Equal("login,orders", mockResult)
+	a.Equal(2, len(report.Scenarios))
+}
@@ -16,6 +16,10 @@ func (s *FuncExec) String(cntx Context) string {
 	return cntx.ExpandVarsNoError(s.name)
 }
 
+func (s *FuncExec) Name() string {
+	return s.name
+}
+
 func (s *FuncExec) Exec(cntx Context) error {
 	return s.f()
 }
@@ -0,0 +1,121 @@
+package exec
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SetupHook prepares a test group, scenario or iteration, e.g. by logging in
+// or creating a tenant. The returned env values are merged into the env
+// of the following hooks and executions, e.g. {"token": "..."}.
+type SetupHook func(cntx Context) (map[string]string, error)
+
+// TeardownHook cleans up after a test group, scenario or iteration.
+// It gets the context with the env values of the setup hooks.
+type TeardownHook func(cntx Context) error
+
+// Hooks are the setup and teardown hooks of a scenario or test group.
+//
+// BeforeAll runs once before the first execution and AfterAll once after the last one.
+// The hooks of a test group run before the first and after the last selected scenario of the group.
+// They get a context with the env of the repository, which is set by SetEnv.
+//
+// BeforeEach and AfterEach run around each execution of the scenario, with its context.
+// They are not part of the measured duration, but their errors fail the execution.
+// The hooks of a test group run around the ones of its scenarios.
+//
+// Once the setup hooks were started, the teardown hooks always run: also if a setup hook
+// or an execution failed, or the run was aborted by a threshold or Repository.Abort.
+type Hooks struct {
+	BeforeAll  []SetupHook
+	AfterAll   []TeardownHook
+	BeforeEach []SetupHook
+	AfterEach  []TeardownHook
+}
+
+// setUp runs the hooks in their order and stops at the first error.
+// The env values of each hook are merged into the context of the following ones and into env, if not nil.
+func setUp(cntx Context, hooks []SetupHook, env map[string]string) (Context, error) {
+	for _, hook := range hooks {
+		values, err := hook(cntx)
+		if err != nil {
+			return cntx, err
+		}
+		if len(values) == 0 {
+			continue
+		}
+		cntx = withEnv(cntx, values)
+		if env != nil {
+			for k, v := range values {
+				env[k] = v
+			}
+		}
+	}
+	return cntx, nil
+}
+
+// tearDown runs all hooks in their order and returns the first error.
+func tearDown(cntx Context, hooks []TeardownHook) error {
+	var firstErr error
+	for _, hook := range hooks {
+		if err := hook(cntx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// hookExecution returns an ended execution for the failed hook of the supplied kind,
+// e.g. "before all", so that the failure is reported like a failed execution.
+func hookExecution(cntx Context, kind string, err error) *Execution {
+	execution := StartExecution(kind, &cntx)
+	execution.End(fmt.Errorf("%v: %v", kind, err))
+	return execution
+}
+
+// groupRun is the state of the BeforeAll and AfterAll hooks of a test group within a repository run.
+// It is safe for concurrent use by the scenarios of the group.
+type groupRun struct {
+	mutex     sync.Mutex
+	hooks     *Hooks
+	cntx      Context
+	env       map[string]string
+	err       error
+	started   bool
+	remaining int
+}
+
+// setUp runs the BeforeAll hooks of the group once and returns their result.
+func (group *groupRun) setUp(base Context) (Context, error) {
+	group.mutex.Lock()
+	defer group.mutex.Unlock()
+	if !group.started {
+		group.started = true
+		group.env = map[string]string{}
+		group.cntx, group.err = setUp(base, group.hooks.BeforeAll, group.env)
+	}
+	return group.cntx, group.err
+}
+
+// done marks a scenario of the group as finished. After the last one,
+// it runs the AfterAll hooks and returns their error.
+func (group *groupRun) done() error {
+	group.mutex.Lock()
+	defer group.mutex.Unlock()
+	group.remaining--
+	if group.remaining > 0 {
+		return nil
+	}
+	return group.tearDown()
+}
+
+// tearDown runs the AfterAll hooks, if the group was set up and not yet torn down.
+// The mutex of the group has to be held.
+func (group *groupRun) tearDown() error {
+	if !group.started {
+		return nil
+	}
+	group.started = false
+	group.remaining = 0
+	return tearDown(group.cntx, group.hooks.AfterAll)
+}
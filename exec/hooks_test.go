@@ -0,0 +1,214 @@
+package exec
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hookLog records the calls of hooks and executions.
+type hookLog struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (log *hookLog) add(call string) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.calls = append(log.calls, call)
+}
+
+func (log *hookLog) setup(name string, env map[string]string) SetupHook {
+	return func(cntx Context) (map[string]string, error) {
+		log.add(name)
+		return env, nil
+	}
+}
+
+func (log *hookLog) teardown(name string, key string) TeardownHook {
+	return func(cntx Context) error {
+		log.add(name + ":" + cntx.Env()[key])
+		return nil
+	}
+}
+
+func contextsFactory(n int) func() chan Context {
+	return func() chan Context {
+		return NewDefaultContext().Populate(n, func(int) map[string]string { return nil })
+	}
+}
+
+func Test_Hooks_Order(t *testing.T) {
+	a := assert.New(t)
+	log := &hookLog{}
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.SetEnv(map[string]string{"host": "localhost"})
+	repo.SetGroupHooks("group", Hooks{
+		BeforeAll:  []SetupHook{log.setup("group before all", map[string]string{"tenant": "t1"})},
+		AfterAll:   []TeardownHook{log.teardown("group after all", "tenant")},
+		BeforeEach: []SetupHook{log.setup("group before each", nil)},
+		AfterEach:  []TeardownHook{log.teardown("group after each", "user")},
+	})
+	for _, name := range []string{"first", "second"} {
+		name := name
+		repo.MustAdd(NewTestScenario(name, contextExec(func(cntx Context) error {
+			log.add(name + ":" + cntx.Env()["host"] + "," + cntx.Env()["tenant"] + "," + cntx.Env()["token"] + "," + cntx.Env()["user"])
+			return nil
+		}), contextsFactory(1)).
+			WithBeforeAll(func(cntx Context) (map[string]string, error) {
+				log.add("before all:" + cntx.Env()["tenant"])
+				return map[string]string{"token": name + "-token"}, nil
+			}).
+			WithAfterAll(log.teardown("after all", "token")).
+			WithBeforeEach(log.setup("before each", map[string]string{"user": name + "-user"})).
+			WithAfterEach(log.teardown("after each", "user")), "group", 1)
+	}
+	repo.RunTestScenarios("", "")
+
+	a.Equal([]string{
+		"group before all",
+		"before all:t1",
+		"group before each",
+		"before each",
+		"first:localhost,t1,first-token,first-user",
+		"after each:first-user",
+		"group after each:first-user",
+		"after all:first-token",
+		"before all:t1",
+		"group before each",
+		"before each",
+		"second:localhost,t1,second-token,second-user",
+		"after each:second-user",
+		"group after each:second-user",
+		"after all:second-token",
+		"group after all:t1",
+	}, log.calls)
+	a.Empty(repo.GetErrorExecutions())
+}
+
+func Test_Hooks_FailingSetup(t *testing.T) {
+	a := assert.New(t)
+	log := &hookLog{}
+	executed := false
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("failing", contextExec(func(cntx Context) error {
+		executed = true
+		return nil
+	}), contextsFactory(1)).
+		WithBeforeAll(func(cntx Context) (map[string]string, error) {
+			return nil, errors.New("login failed")
+		}).
+		WithAfterAll(log.teardown("after all", "token")), "group", 1)
+	repo.RunTestScenarios("", "")
+
+	a.False(executed)
+	a.Equal([]string{"after all:"}, log.calls)
+	errs := repo.GetErrorExecutions()
+	a.Equal(1, len(errs))
+	a.Equal("before all: login failed", errs[0].Error().Error())
+	a.Equal(1, repo.lastReport.Scenarios[0].Run.Stats.Total().Errors)
+}
+
+func Test_Hooks_FailingEach(t *testing.T) {
+	a := assert.New(t)
+	executed := 0
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("each", contextExec(func(cntx Context) error {
+		executed++
+		return nil
+	}), contextsFactory(4)).
+		WithBeforeEach(func(cntx Context) (map[string]string, error) {
+			if cntx.TestNumber() == 2 {
+				return nil, errors.New("no user")
+			}
+			return nil, nil
+		}).
+		WithAfterEach(func(cntx Context) error {
+			if cntx.TestNumber() == 3 {
+				return errors.New("cleanup failed")
+			}
+			return nil
+		}), "group", 1)
+	repo.RunTestScenarios("", "")
+
+	a.Equal(3, executed)
+	messages := []string{}
+	for _, execution := range repo.GetErrorExecutions() {
+		messages = append(messages, execution.Error().Error())
+	}
+	a.Equal([]string{"before each: no user", "after each: cleanup failed"}, messages)
+}
+
+func Test_Hooks_TeardownOnThresholdAbort(t *testing.T) {
+	a := assert.New(t)
+	log := &hookLog{}
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.SetGroupHooks("abort", Hooks{AfterAll: []TeardownHook{log.teardown("group after all", "")}})
+	repo.MustAdd(NewTestScenario("aborted", F("slow error", func() error {
+		time.Sleep(5 * time.Millisecond)
+		return errors.New("failed")
+	}), contextsFactory(1000)).
+		WithThresholds(MustThreshold("error_rate < 10%").AbortOnFail(time.Millisecond)).
+		WithAfterAll(log.teardown("after all", "")), "abort", 1)
+	repo.RunTestScenarios("", "")
+
+	a.NotNil(repo.lastReport.Scenarios[0].Run.AbortedBy)
+	a.Equal([]string{"after all:", "group after all:"}, log.calls)
+}
+
+func Test_Hooks_TeardownOnAbort(t *testing.T) {
+	a := assert.New(t)
+	log := &hookLog{}
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.SetGroupHooks("abort", Hooks{
+		BeforeAll: []SetupHook{log.setup("group before all", nil)},
+		AfterAll:  []TeardownHook{log.teardown("group after all", "")},
+	})
+	repo.MustAdd(NewTestScenario("aborted", F("slow", func() error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}), contextsFactory(10000)).
+		WithAfterAll(log.teardown("after all", "")), "abort", 1)
+	repo.MustAdd(NewTestScenario("skipped", newMockExec("skipped"), newChannelFactory()).
+		WithBeforeAll(log.setup("skipped before all", nil)), "abort", 1)
+
+	time.AfterFunc(20*time.Millisecond, repo.Abort)
+	repo.RunTestScenarios("", "")
+
+	a.Equal(2, len(repo.lastReport.Scenarios))
+	a.Equal(AbortedSkipReason, repo.lastReport.Scenarios[1].Run.SkipReason)
+	a.Equal([]string{"group before all", "after all:", "group after all:"}, log.calls)
+	a.True(len(repo.lastReport.Scenarios[0].Executions) < 10000)
+}
+
+func Test_Hooks_SecretsFromSetupAreRedacted(t *testing.T) {
+	a := assert.New(t)
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MarkSecret("hookToken")
+	repo.MustAdd(NewTestScenario("secret", contextExec(func(cntx Context) error {
+		return errors.New("invalid token " + cntx.Env()["hookToken"])
+	}), contextsFactory(1)).
+		WithBeforeAll(func(cntx Context) (map[string]string, error) {
+			return map[string]string{"hookToken": "s3cr3t"}, nil
+		}), "group", 1)
+	repo.RunTestScenarios("", "")
+
+	errs := repo.GetErrorExecutions()
+	a.Equal(1, len(errs))
+	a.False(strings.Contains(errs[0].Error().Error(), "s3cr3t"))
+}
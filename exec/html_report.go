@@ -0,0 +1,313 @@
+package exec
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTMLReporter collects all executions of a repository run
+// and writes a self-contained HTML report at the end of the run.
+type HTMLReporter struct {
+	path       string
+	mutex      sync.Mutex
+	executions []*Execution
+}
+
+// NewHTMLReporter creates a reporter, which writes the report to the file at path.
+func NewHTMLReporter(path string) *HTMLReporter {
+	return &HTMLReporter{path: path}
+}
+
+func (reporter *HTMLReporter) ScenarioStarted(run *ScenarioRun) {
+}
+
+func (reporter *HTMLReporter) Report(run *ScenarioRun, execution *Execution) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.executions = append(reporter.executions, execution)
+}
+
+func (reporter *HTMLReporter) ScenarioFinished(run *ScenarioRun) {
+}
+
+// RunFinished writes the report and resets the reporter.
+func (reporter *HTMLReporter) RunFinished() error {
+	reporter.mutex.Lock()
+	executions := reporter.executions
+	reporter.executions = nil
+	reporter.mutex.Unlock()
+
+	f, err := os.Create(reporter.path)
+	if err != nil {
+		return err
+	}
+	err = WriteHTMLReport(f, executions)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// number of time buckets in the charts
+const htmlChartBuckets = 60
+
+// number of error messages shown per scenario
+const htmlTopErrors = 10
+
+type htmlReport struct {
+	Created   string
+	Scenarios []*htmlScenario
+}
+
+type htmlScenario struct {
+	Name      string
+	TestGroup string
+	Total     StatsSummary
+	Steps     []StatsSummary
+	Charts    []*htmlChart
+	Errors    []*htmlError
+}
+
+type htmlError struct {
+	Message        string
+	Count          int
+	CorrelationIds []string
+}
+
+type htmlChart struct {
+	Title  string
+	Unit   string
+	YMax   string
+	XMax   string
+	Series []htmlSeries
+}
+
+type htmlSeries struct {
+	Name   string
+	Color  string
+	Points string
+}
+
+const (
+	htmlChartWidth  = 600
+	htmlChartHeight = 200
+)
+
+// WriteHTMLReport writes a self-contained HTML report of the executions to w.
+// The executions are grouped by their test group and scenario.
+func WriteHTMLReport(w io.Writer, executions []*Execution) error {
+	report := &htmlReport{Created: time.Now().Format(time.RFC1123)}
+	for _, group := range groupByScenario(executions) {
+		report.Scenarios = append(report.Scenarios, newHTMLScenario(group))
+	}
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// groupByScenario groups the executions by test group and scenario
+// in the order of their first occurrence.
+func groupByScenario(executions []*Execution) [][]*Execution {
+	groups := [][]*Execution{}
+	index := map[[2]string]int{}
+	for _, execution := range executions {
+		key := [2]string{execution.testGroup, execution.scenario}
+		i, exists := index[key]
+		if !exists {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], execution)
+	}
+	return groups
+}
+
+func newHTMLScenario(executions []*Execution) *htmlScenario {
+	stats := NewStats()
+	for _, execution := range executions {
+		stats.Add(execution)
+	}
+	scenario := &htmlScenario{
+		Name:      executions[0].scenario,
+		TestGroup: executions[0].testGroup,
+		Total:     stats.Total(),
+		Steps:     stats.Steps(),
+		Errors:    topErrors(executions),
+	}
+	scenario.Charts = timeCharts(executions, scenario.Total.First, scenario.Total.Last)
+	return scenario
+}
+
+func topErrors(executions []*Execution) []*htmlError {
+	byMessage := map[string]*htmlError{}
+	errs := []*htmlError{}
+	for _, execution := range executions {
+		if execution.err == nil {
+			continue
+		}
+		message := execution.err.Error()
+		e, exists := byMessage[message]
+		if !exists {
+			e = &htmlError{Message: message}
+			byMessage[message] = e
+			errs = append(errs, e)
+		}
+		e.Count++
+		if len(e.CorrelationIds) < 3 {
+			e.CorrelationIds = append(e.CorrelationIds, execution.context.CorrelationId())
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Count > errs[j].Count })
+	if len(errs) > htmlTopErrors {
+		errs = errs[:htmlTopErrors]
+	}
+	return errs
+}
+
+// timeCharts creates the latency, throughput and error rate charts
+// by splitting the time between first and last into buckets.
+// Each execution is assigned to the bucket of its end.
+func timeCharts(executions []*Execution, first, last time.Time) []*htmlChart {
+	span := last.Sub(first)
+	bucketSize := span / htmlChartBuckets
+	if bucketSize < time.Millisecond {
+		bucketSize = time.Millisecond
+	}
+	bucketCount := int(span/bucketSize) + 1
+	buckets := make([]*Stats, bucketCount)
+	for i := range buckets {
+		buckets[i] = NewStats()
+	}
+	for _, execution := range executions {
+		i := int(execution.end.Sub(first) / bucketSize)
+		if i >= bucketCount {
+			i = bucketCount - 1
+		}
+		buckets[i].Add(execution)
+	}
+
+	p50 := make([]float64, bucketCount)
+	p95 := make([]float64, bucketCount)
+	p99 := make([]float64, bucketCount)
+	throughput := make([]float64, bucketCount)
+	errorRate := make([]float64, bucketCount)
+	for i, bucket := range buckets {
+		summary := bucket.Total()
+		p50[i] = summary.P50.Seconds() * 1000
+		p95[i] = summary.P95.Seconds() * 1000
+		p99[i] = summary.P99.Seconds() * 1000
+		throughput[i] = float64(summary.Count) / bucketSize.Seconds()
+		errorRate[i] = summary.ErrorRate() * 100
+	}
+
+	xMax := formatDuration(bucketSize * time.Duration(bucketCount))
+	return []*htmlChart{
+		newHTMLChart("Latency percentiles", "ms", xMax, []string{"p50", "p95", "p99"}, p50, p95, p99),
+		newHTMLChart("Throughput", "rps", xMax, []string{"rps"}, throughput),
+		newHTMLChart("Error rate", "%", xMax, []string{"errors"}, errorRate),
+	}
+}
+
+var htmlChartColors = []string{"#1f77b4", "#ff7f0e", "#d62728"}
+
+func newHTMLChart(title, unit, xMax string, names []string, values ...[]float64) *htmlChart {
+	max := 0.0
+	for _, series := range values {
+		for _, v := range series {
+			if v > max {
+				max = v
+			}
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+
+	chart := &htmlChart{
+		Title: title,
+		Unit:  unit,
+		YMax:  fmt.Sprintf("%.1f %v", max, unit),
+		XMax:  xMax,
+	}
+	for i, series := range values {
+		points := make([]string, len(series))
+		for j, v := range series {
+			x := float64(htmlChartWidth) / float64(len(series)) * (float64(j) + 0.5)
+			y := float64(htmlChartHeight) - v/max*float64(htmlChartHeight)
+			points[j] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+		chart.Series = append(chart.Series, htmlSeries{
+			Name:   names[i],
+			Color:  htmlChartColors[i%len(htmlChartColors)],
+			Points: strings.Join(points, " "),
+		})
+	}
+	return chart
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": formatDuration,
+	"percent":  func(f float64) string { return fmt.Sprintf("%.2f%%", f*100) },
+	"rate":     func(f float64) string { return fmt.Sprintf("%.1f", f) },
+	"width":    func() int { return htmlChartWidth },
+	"height":   func() int { return htmlChartHeight },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>godriver report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+tr.total { font-weight: bold; }
+.charts { display: flex; flex-wrap: wrap; gap: 1em; }
+.chart svg { background: #fafafa; border: 1px solid #ccc; }
+.legend span { margin-right: 1em; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>godriver report</h1>
+<p>Created {{.Created}}</p>
+{{range .Scenarios}}
+<h2>{{.TestGroup}} / {{.Name}}</h2>
+<table>
+<tr><th>Step</th><th>Count</th><th>Errors</th><th>Error rate</th><th>RPS</th><th>Min</th><th>Mean</th><th>P50</th><th>P90</th><th>P95</th><th>P99</th><th>Max</th></tr>
+{{with .Total}}<tr class="total"><td>total</td>{{template "row" .}}</tr>{{end}}
+{{range .Steps}}<tr><td>{{.Name}}</td>{{template "row" .}}</tr>
+{{end}}
+</table>
+<div class="charts">
+{{range .Charts}}
+<div class="chart">
+<h3>{{.Title}}</h3>
+<svg width="{{width}}" height="{{height}}" viewBox="0 0 {{width}} {{height}}" xmlns="http://www.w3.org/2000/svg">
+<text x="4" y="12" font-size="10">{{.YMax}}</text>
+<text x="{{width}}" y="{{height}}" dx="-4" dy="-4" font-size="10" text-anchor="end">{{.XMax}}</text>
+{{range .Series}}<polyline fill="none" stroke="{{.Color}}" stroke-width="1.5" points="{{.Points}}"/>
+{{end}}
+</svg>
+<div class="legend">{{range .Series}}<span style="color: {{.Color}}">&#9632; {{.Name}}</span>{{end}}</div>
+</div>
+{{end}}
+</div>
+{{if .Errors}}
+<h3>Top errors</h3>
+<table>
+<tr><th>Message</th><th>Count</th><th>Example correlation ids</th></tr>
+{{range .Errors}}<tr><td><code>{{.Message}}</code></td><td>{{.Count}}</td><td>{{range .CorrelationIds}}<code>{{.}}</code> {{end}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{end}}
+</body>
+</html>
+{{define "row"}}<td>{{.Count}}</td><td>{{.Errors}}</td><td>{{percent .ErrorRate}}</td><td>{{rate .Throughput}}</td><td>{{duration .Min}}</td><td>{{duration .Mean}}</td><td>{{duration .P50}}</td><td>{{duration .P90}}</td><td>{{duration .P95}}</td><td>{{duration .P99}}</td><td>{{duration .Max}}</td>{{end}}
+`))
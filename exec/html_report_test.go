@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WriteHTMLReport(t *testing.T) {
+	a := assert.New(t)
+	start := time.Now()
+
+	executions := []*Execution{}
+	for i := 0; i < 100; i++ {
+		var err error
+		if i%10 == 0 {
+			err = errors.New("failed <badly>")
+		}
+		execution := newTestExecution("scenario", start.Add(time.Duration(i)*10*time.Millisecond), time.Duration(i)*time.Millisecond, err,
+			newTestExecution("login", start, time.Millisecond, nil))
+		execution.setScenario("scenario1", "group1")
+		executions = append(executions, execution)
+	}
+	other := newTestExecution("other", start, time.Millisecond, errors.New("other error"))
+	other.setScenario("scenario2", "group1")
+	executions = append(executions, other)
+
+	out := bytes.NewBuffer(nil)
+	a.NoError(WriteHTMLReport(out, executions))
+	html := out.String()
+
+	a.Contains(html, "<h2>group1 / scenario1</h2>")
+	a.Contains(html, "<h2>group1 / scenario2</h2>")
+	a.Contains(html, `<tr class="total"><td>total</td><td>100</td><td>10</td><td>10.00%</td>`)
+	a.Contains(html, "<tr><td>login</td><td>100</td><td>0</td>")
+	a.Contains(html, "<td><code>failed &lt;badly&gt;</code></td><td>10</td>")
+	a.Contains(html, "<td><code>other error</code></td><td>1</td>")
+	a.Equal(6, strings.Count(html, "<svg "))
+	a.Contains(html, `<polyline fill="none" stroke="#1f77b4"`)
+	a.NotContains(html, "ZgotmplZ")
+	// no external assets
+	a.NotContains(html, "src=")
+	a.NotContains(html, "href=")
+}
+
+func Test_HTMLReporter(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "html")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "report.html")
+	repo := NewRepository()
+	repo.SetReporters(NewHTMLReporter(path))
+	repo.MustAdd(NewTestScenario("spec", newMockErrorExecution("spec"), newChannelFactory()), "html", 1)
+	repo.RunTestScenarios("html", "")
+
+	content, err := ioutil.ReadFile(path)
+	a.NoError(err)
+	a.Contains(string(content), "<h2>html / spec</h2>")
+	a.Contains(string(content), "Wanted error on &#34;spec&#34;")
+}
@@ -17,8 +17,17 @@ type HttpExec struct {
 	Body               []byte
 	expectations       []HttpExpectation
 	codeExpectationSet bool
+	name               string
+	// secrets are templates of values, which are redacted from errors
+	secrets []string
+	// basicAuth contains the templates of the username and password
+	basicAuth *[2]string
 }
 
+// TraceStateKey is the key of the test data or env entry, which is sent as
+// W3C tracestate header. The test data takes precedence over the env.
+const TraceStateKey = "tracestate"
+
 type HttpExpectation func(response *http.Response, body string) error
 
 func Get(url string) *HttpExec {
@@ -38,14 +47,48 @@ func Post(url string, contentType string, body string) *HttpExec {
 	}
 }
 
+// WithAuthorization sets the authorization header. Its value is redacted from errors.
 func (httpExec *HttpExec) WithAuthorization(authorizationHeader string) *HttpExec {
+	httpExec.basicAuth = nil
 	httpExec.Header.Set("Authorization", authorizationHeader)
+	httpExec.secrets = append(httpExec.secrets, authorizationHeader)
 	return httpExec
 }
 
+// WithBasicAuth sets the authorization header for basic authentication.
+// The username and password are templates, which are expanded for each request,
+// e.g. {{.Env.password}}. The password and the header value are redacted from errors.
 func (httpExec *HttpExec) WithBasicAuth(username, password string) *HttpExec {
-	enc := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
-	return httpExec.WithAuthorization("Basic " + enc)
+	httpExec.Header.Del("Authorization")
+	httpExec.basicAuth = &[2]string{username, password}
+	return httpExec
+}
+
+// basicAuthHeader returns the expanded password and the authorization header for the context.
+func (httpExec *HttpExec) basicAuthHeader(cntx Context) (password, header string, err error) {
+	username, err := cntx.ExpandVars(httpExec.basicAuth[0])
+	if err != nil {
+		return "", "", err
+	}
+	if password, err = cntx.ExpandVars(httpExec.basicAuth[1]); err != nil {
+		return "", "", err
+	}
+	return password, "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+}
+
+// Named sets the name of the request, which is used to aggregate its executions.
+func (httpExec *HttpExec) Named(name string) *HttpExec {
+	httpExec.name = name
+	return httpExec
+}
+
+// Name returns the name set by Named,
+// or the method and url template otherwise.
+func (httpExec *HttpExec) Name() string {
+	if httpExec.name != "" {
+		return httpExec.name
+	}
+	return fmt.Sprintf("->%v %v", httpExec.Method, httpExec.Url)
 }
 
 func (httpExec *HttpExec) String(cntx Context) string {
@@ -76,7 +119,7 @@ func (httpExec *HttpExec) HasNonErrorCode() *HttpExec {
 func (httpExec *HttpExec) HasCode(code int) *HttpExec {
 	httpExec.Expect(func(resp *http.Response, body string) error {
 		if resp.StatusCode != code {
-			return fmt.Errorf("response code was %v, but expected: %v", resp, code)
+			return fmt.Errorf("response code was %v, but expected: %v", resp.StatusCode, code)
 		}
 		return nil
 	})
@@ -87,7 +130,7 @@ func (httpExec *HttpExec) HasCode(code int) *HttpExec {
 func (httpExec *HttpExec) HasCodeRange(min, max int) *HttpExec {
 	httpExec.Expect(func(resp *http.Response, body string) error {
 		if !(min <= resp.StatusCode && resp.StatusCode <= max) {
-			return fmt.Errorf("response code was %v, but expected: %v <= code <= %v", resp, min, max)
+			return fmt.Errorf("response code was %v, but expected: %v <= code <= %v", resp.StatusCode, min, max)
 		}
 		return nil
 	})
@@ -127,7 +170,49 @@ func (httpExec *HttpExec) SelectorContains(selector, substring string) *HttpExec
 	return httpExec
 }
 
+// Validate parses the templates of the url, headers and body.
+func (httpExec *HttpExec) Validate() error {
+	if err := ValidateTemplate(httpExec.Url); err != nil {
+		return fmt.Errorf("%v: url: %v", httpExec.Name(), err)
+	}
+	for k := range httpExec.Header {
+		if err := ValidateTemplate(httpExec.Header.Get(k)); err != nil {
+			return fmt.Errorf("%v: header %v: %v", httpExec.Name(), k, err)
+		}
+	}
+	if err := ValidateTemplate(string(httpExec.Body)); err != nil {
+		return fmt.Errorf("%v: body: %v", httpExec.Name(), err)
+	}
+	if httpExec.basicAuth != nil {
+		for _, value := range httpExec.basicAuth {
+			if err := ValidateTemplate(value); err != nil {
+				return fmt.Errorf("%v: basic auth: %v", httpExec.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Exec executes the request and checks the expectations.
+// Secret values of the context and the credentials are redacted from the returned error.
 func (httpExec *HttpExec) Exec(cntx Context) error {
+	err := httpExec.exec(cntx)
+	if err == nil {
+		return nil
+	}
+	secrets := make([]string, len(httpExec.secrets))
+	for i, secret := range httpExec.secrets {
+		secrets[i] = cntx.ExpandVarsNoError(secret)
+	}
+	if httpExec.basicAuth != nil {
+		if password, header, authErr := httpExec.basicAuthHeader(cntx); authErr == nil {
+			secrets = append(secrets, password, header)
+		}
+	}
+	return redactError(cntx, err, secrets...)
+}
+
+func (httpExec *HttpExec) exec(cntx Context) error {
 	url, err := cntx.ExpandVars(string(httpExec.Url))
 	if err != nil {
 		return err
@@ -155,7 +240,22 @@ func (httpExec *HttpExec) Exec(cntx Context) error {
 		}
 		req.Header.Set(k, v)
 	}
-	req.Header.Add("X-Correlation-Id", cntx.CorrelationId())
+	if httpExec.basicAuth != nil {
+		_, header, err := httpExec.basicAuthHeader(cntx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", header)
+	}
+	for _, header := range correlationOf(cntx).headers() {
+		req.Header.Add(header, cntx.CorrelationId())
+	}
+	if execution := executionOf(cntx); execution != nil {
+		req.Header.Set("traceparent", execution.TraceParent())
+		if traceState := traceStateOf(cntx); traceState != "" {
+			req.Header.Set("tracestate", traceState)
+		}
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -180,3 +280,10 @@ func (httpExec *HttpExec) Exec(cntx Context) error {
 
 	return nil
 }
+
+func traceStateOf(cntx Context) string {
+	if traceState := cntx.Test()[TraceStateKey]; traceState != "" {
+		return traceState
+	}
+	return cntx.Env()[TraceStateKey]
+}
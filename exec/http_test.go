@@ -105,3 +105,62 @@ func Test_Http_Post(t *testing.T) {
 	a.Error(Post("h :// invalid", "application/foo", "demo data").
 		Exec(cntx))
 }
+
+func Test_Http_TraceHeaders(t *testing.T) {
+	a := assert.New(t)
+
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+	}))
+	defer server.Close()
+
+	// no trace headers outside of a run
+	a.NoError(Get(server.URL).Exec(NewDefaultContext()))
+	a.Equal("", (<-headers).Get("traceparent"))
+
+	var cntx Context = NewContext(map[string]string{TraceStateKey: "godriver=env"})
+	execution := StartExecution("request", &cntx)
+	a.NoError(Get(server.URL).Exec(cntx))
+	h := <-headers
+	a.Equal(execution.TraceParent(), h.Get("traceparent"))
+	a.Equal("godriver=env", h.Get("tracestate"))
+
+	cntx = cntx.Derive(map[string]string{TraceStateKey: "godriver=test"})
+	StartExecution("request", &cntx)
+	a.NoError(Get(server.URL).Exec(cntx))
+	a.Equal("godriver=test", (<-headers).Get("tracestate"))
+}
+
+func Test_Http_Validate(t *testing.T) {
+	a := assert.New(t)
+
+	a.NoError(Get("http://{{.Env.host}}/").Validate())
+	a.EqualError(Get("http://{{.Env.host").Named("home").Validate(),
+		`home: url: template: template:1: unclosed action`)
+	a.Error(Post("/", "{{.Env.type", "").Validate())
+	a.Error(Post("/", "text/plain", "{{end}}").Validate())
+}
+
+// Benchmark_Http_Exec measures the overhead of the driver per request,
+// including the local test server.
+func Benchmark_Http_Exec(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	cntx := NewContext(map[string]string{"host": server.URL})
+	cntx.Test()["id"] = "4711"
+	exec := Post("{{.Env.host}}/items/{{.Test.id}}", "application/json", `{"id": "{{.Test.id}}"}`).
+		WithAuthorization("Bearer {{.Test.id}}").
+		Contains("ok")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := exec.Exec(cntx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
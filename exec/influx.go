@@ -0,0 +1,126 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// InfluxReporter pushes the executions in the InfluxDB line protocol
+// to an InfluxDB write endpoint, e.g. http://localhost:8086/write?db=godriver.
+// The points are written in batches of BatchSize and at the end of each scenario.
+// The batches are sent in the background, RunFinished waits for them.
+//
+// Each execution is written as measurement godriver_execution
+// and each step as godriver_step with the tags test_group, scenario, step,
+// status and the configured labels, and the fields duration_ms and error.
+type InfluxReporter struct {
+	// BatchSize is the maximum number of points per request.
+	BatchSize int
+	// Client is the http client used for writing.
+	Client *http.Client
+
+	url    string
+	labels []metricTag
+	mutex  sync.Mutex
+	batch  *bytes.Buffer
+	points int
+	sender *batchSender
+}
+
+// NewInfluxReporter creates a reporter writing to the supplied url,
+// with labels added as tags to each point.
+func NewInfluxReporter(url string, labels map[string]string) *InfluxReporter {
+	reporter := &InfluxReporter{
+		BatchSize: 1000,
+		Client:    http.DefaultClient,
+		url:       url,
+		labels:    sortedTags(labels),
+		batch:     bytes.NewBuffer(nil),
+	}
+	reporter.sender = newBatchSender(reporter.post)
+	return reporter
+}
+
+func (reporter *InfluxReporter) ScenarioStarted(run *ScenarioRun) {
+}
+
+func (reporter *InfluxReporter) Report(run *ScenarioRun, execution *Execution) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	for _, point := range executionPoints(execution, reporter.labels) {
+		writeInfluxLine(reporter.batch, point)
+		reporter.points++
+		if reporter.points >= reporter.BatchSize {
+			reporter.flush()
+		}
+	}
+}
+
+func (reporter *InfluxReporter) ScenarioFinished(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.flush()
+}
+
+// RunFinished writes the remaining points, waits for the sent batches
+// and returns the first write error since the last call.
+func (reporter *InfluxReporter) RunFinished() error {
+	reporter.mutex.Lock()
+	reporter.flush()
+	reporter.mutex.Unlock()
+	return reporter.sender.drain()
+}
+
+// flush queues the current batch for sending.
+func (reporter *InfluxReporter) flush() {
+	if reporter.points == 0 {
+		return
+	}
+	reporter.sender.send(reporter.batch.Bytes())
+	reporter.batch = bytes.NewBuffer(nil)
+	reporter.points = 0
+}
+
+func (reporter *InfluxReporter) post(body []byte) error {
+	resp, err := reporter.Client.Post(reporter.url, "text/plain; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("influx write returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+func writeInfluxLine(b *bytes.Buffer, point metricPoint) {
+	b.WriteString(influxMeasurementEscaper.Replace("godriver_" + point.measurement))
+	for _, tag := range point.tags {
+		if tag.value == "" {
+			// empty tag values are not allowed
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(influxTagEscaper.Replace(tag.key))
+		b.WriteByte('=')
+		b.WriteString(influxTagEscaper.Replace(tag.value))
+	}
+	failed := 0
+	if point.failed {
+		failed = 1
+	}
+	fmt.Fprintf(b, " duration_ms=%v,error=%vi %v\n",
+		strconv.FormatFloat(point.duration.Seconds()*1000, 'f', -1, 64), failed, point.time.UnixNano())
+}
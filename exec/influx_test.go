@@ -0,0 +1,114 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newSinkTestExecution(err error) *Execution {
+	start := time.Unix(1500000000, 0)
+	execution := newTestExecution("spec", start, 12500*time.Microsecond, err,
+		newTestExecution("log in", start, 2*time.Millisecond, err))
+	execution.setScenario("spec", "group,1")
+	return execution
+}
+
+func Test_InfluxReporter(t *testing.T) {
+	a := assert.New(t)
+
+	var mutex sync.Mutex
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		a.Equal("/write", req.URL.Path)
+		a.Equal("godriver", req.URL.Query().Get("db"))
+		body, _ := ioutil.ReadAll(req.Body)
+		mutex.Lock()
+		requests = append(requests, string(body))
+		mutex.Unlock()
+		resp.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	reporter := NewInfluxReporter(server.URL+"/write?db=godriver", map[string]string{"run": "nightly", "env": "dev"})
+	reporter.BatchSize = 3
+	reporter.Report(nil, newSinkTestExecution(nil))
+	reporter.Report(nil, newSinkTestExecution(errors.New("failed")))
+	a.NoError(reporter.RunFinished())
+
+	a.Equal(2, len(requests))
+	lines := strings.Split(strings.TrimSpace(strings.Join(requests, "")), "\n")
+	a.Equal(4, len(lines))
+	a.Equal(`godriver_execution,test_group=group\,1,scenario=spec,status=ok,env=dev,run=nightly duration_ms=12.5,error=0i 1500000000012500000`, lines[0])
+	a.Equal(`godriver_step,test_group=group\,1,scenario=spec,step=log\ in,status=ok,env=dev,run=nightly duration_ms=2,error=0i 1500000000002000000`, lines[1])
+	a.Equal(`godriver_step,test_group=group\,1,scenario=spec,step=log\ in,status=error,env=dev,run=nightly duration_ms=2,error=1i 1500000000002000000`, lines[3])
+}
+
+func Test_InfluxReporter_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(400)
+	}))
+	defer server.Close()
+
+	reporter := NewInfluxReporter(server.URL+"/write", nil)
+	reporter.Report(nil, newSinkTestExecution(nil))
+	assert.EqualError(t, reporter.RunFinished(), "influx write returned status 400")
+	assert.NoError(t, reporter.RunFinished())
+}
+
+func Test_InfluxReporter_SlowEndpoint(t *testing.T) {
+	a := assert.New(t)
+
+	var mutex sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+		resp.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	reporter := NewInfluxReporter(server.URL+"/write", nil)
+	reporter.BatchSize = 1
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		reporter.Report(nil, newSinkTestExecution(nil))
+	}
+	reporter.ScenarioFinished(nil)
+	a.True(time.Since(start) < 50*time.Millisecond)
+
+	a.NoError(reporter.RunFinished())
+	a.True(time.Since(start) >= 6*50*time.Millisecond)
+	a.Equal(6, requests)
+}
+
+func Test_InfluxReporter_DropsBatches(t *testing.T) {
+	a := assert.New(t)
+
+	blocked := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		<-blocked
+		resp.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	reporter := NewInfluxReporter(server.URL+"/write", nil)
+	reporter.BatchSize = 2
+	for i := 0; i < maxQueuedBatches+10; i++ {
+		reporter.Report(nil, newSinkTestExecution(nil))
+	}
+	close(blocked)
+
+	err := reporter.RunFinished()
+	a.Error(err)
+	a.Contains(err.Error(), "batches dropped, because the endpoint was too slow")
+	a.NoError(reporter.RunFinished())
+}
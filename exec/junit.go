@@ -0,0 +1,290 @@
+package exec
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JUnitReporter collects the results of a repository run
+// and writes them as JUnit XML at the end of the run.
+// Each test group becomes a testsuite. Each scenario becomes a testcase,
+// or each execution, if the reporter is configured with PerExecution.
+type JUnitReporter struct {
+	// PerExecution reports every execution as own testcase, which
+	// is useful for functional runs with a small number of executions.
+	PerExecution bool
+
+	open       func() (io.WriteCloser, error)
+	mutex      sync.Mutex
+	suites     []*junitTestSuite
+	executions map[*ScenarioRun][]*Execution
+}
+
+// junitMaxFailures is the number of failed executions, which are kept per scenario testcase.
+// The others are only counted, so that long runs do not produce huge reports.
+const junitMaxFailures = 10
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Skipped   int              `xml:"skipped,attr,omitempty"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr"`
+	Cases     []*junitTestCase `xml:"testcase"`
+	duration  time.Duration
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitReporter creates a reporter, which writes the XML to the file at path.
+func NewJUnitReporter(path string) *JUnitReporter {
+	return newJUnitReporter(func() (io.WriteCloser, error) {
+		return os.Create(path)
+	})
+}
+
+// NewJUnitWriterReporter creates a reporter, which writes the XML to w.
+func NewJUnitWriterReporter(w io.Writer) *JUnitReporter {
+	return newJUnitReporter(func() (io.WriteCloser, error) {
+		return nopWriteCloser{w}, nil
+	})
+}
+
+func newJUnitReporter(open func() (io.WriteCloser, error)) *JUnitReporter {
+	return &JUnitReporter{
+		open:       open,
+		executions: make(map[*ScenarioRun][]*Execution),
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func (reporter *JUnitReporter) ScenarioStarted(run *ScenarioRun) {
+}
+
+func (reporter *JUnitReporter) Report(run *ScenarioRun, execution *Execution) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	if reporter.PerExecution || execution.err != nil && len(reporter.executions[run]) < junitMaxFailures {
+		reporter.executions[run] = append(reporter.executions[run], execution)
+	}
+}
+
+func (reporter *JUnitReporter) ScenarioFinished(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	executions := reporter.executions[run]
+	delete(reporter.executions, run)
+
+	suite := reporter.suite(run)
+	suite.duration += run.Elapsed()
+	if reporter.PerExecution {
+		for _, execution := range executions {
+			suite.add(executionTestCase(run, execution))
+		}
+		if run.Verdict != nil && len(run.Verdict.Results) > 0 {
+			suite.add(verdictTestCase(run))
+		}
+		return
+	}
+	suite.add(scenarioTestCase(run, executions))
+}
+
+// ScenarioSkipped adds the skipped scenario as skipped testcase.
+func (reporter *JUnitReporter) ScenarioSkipped(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.suite(run).add(&junitTestCase{
+		Name:      run.Name,
+		Classname: run.TestGroup,
+		Time:      junitSeconds(0),
+		Skipped:   &junitSkipped{Message: run.SkipReason},
+	})
+}
+
+// RunFinished writes the collected results and resets the reporter.
+func (reporter *JUnitReporter) RunFinished() error {
+	reporter.mutex.Lock()
+	suites := &junitTestSuites{Suites: reporter.suites}
+	reporter.suites = nil
+	reporter.mutex.Unlock()
+
+	var duration time.Duration
+	for _, suite := range suites.Suites {
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suite.Time = junitSeconds(suite.duration)
+		duration += suite.duration
+	}
+	suites.Time = junitSeconds(duration)
+
+	w, err := reporter.open()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header)
+	if err == nil {
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		err = encoder.Encode(suites)
+	}
+	if err == nil {
+		_, err = io.WriteString(w, "\n")
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (reporter *JUnitReporter) suite(run *ScenarioRun) *junitTestSuite {
+	for _, suite := range reporter.suites {
+		if suite.Name == run.TestGroup {
+			return suite
+		}
+	}
+	suite := &junitTestSuite{
+		Name:      run.TestGroup,
+		Timestamp: run.Start.Format("2006-01-02T15:04:05"),
+	}
+	reporter.suites = append(reporter.suites, suite)
+	return suite
+}
+
+func (suite *junitTestSuite) add(testCase *junitTestCase) {
+	suite.Tests++
+	if testCase.Failure != nil {
+		suite.Failures++
+	}
+	if testCase.Skipped != nil {
+		suite.Skipped++
+	}
+	suite.Cases = append(suite.Cases, testCase)
+}
+
+// scenarioTestCase creates the testcase of the scenario with the failure texts
+// of the supplied executions. Further failed executions of the stats are only counted.
+func scenarioTestCase(run *ScenarioRun, errorExecutions []*Execution) *junitTestCase {
+	total := run.Stats.Total()
+	testCase := &junitTestCase{
+		Name:      run.Name,
+		Classname: run.TestGroup,
+		Time:      junitSeconds(run.Elapsed()),
+		SystemOut: fmt.Sprintf("%v executions, %v errors, %.1f rps, p50 %v, p95 %v, p99 %v",
+			total.Count, total.Errors, total.Throughput(),
+			formatDuration(total.P50), formatDuration(total.P95), formatDuration(total.P99)),
+	}
+
+	messages := []string{}
+	text := []string{}
+	failed := total.Errors
+	if failed < len(errorExecutions) {
+		failed = len(errorExecutions)
+	}
+	if failed > 0 {
+		messages = append(messages, fmt.Sprintf("%v of %v executions failed", failed, total.Count))
+		for _, execution := range errorExecutions {
+			text = append(text, executionFailureText(execution))
+		}
+		if omitted := failed - len(errorExecutions); omitted > 0 {
+			text = append(text, fmt.Sprintf("... %v more failed executions", omitted))
+		}
+	}
+	if run.Verdict != nil {
+		for _, result := range run.Verdict.Failed() {
+			messages = append(messages, "threshold "+result.String())
+			text = append(text, "threshold "+result.String())
+		}
+	}
+	if len(messages) > 0 {
+		testCase.Failure = &junitFailure{
+			Message: strings.Join(messages, "; "),
+			Type:    "failure",
+			Text:    strings.Join(text, "\n"),
+		}
+	}
+	return testCase
+}
+
+func executionTestCase(run *ScenarioRun, execution *Execution) *junitTestCase {
+	testCase := &junitTestCase{
+		Name:      fmt.Sprintf("#%v %v", execution.context.TestNumber(), execution.jobTitle),
+		Classname: run.TestGroup + "." + run.Name,
+		Time:      junitSeconds(execution.Duration()),
+	}
+	if execution.err != nil {
+		testCase.Failure = &junitFailure{
+			Message: execution.err.Error(),
+			Type:    "error",
+			Text:    executionFailureText(execution),
+		}
+	}
+	return testCase
+}
+
+func verdictTestCase(run *ScenarioRun) *junitTestCase {
+	testCase := &junitTestCase{
+		Name:      "thresholds",
+		Classname: run.TestGroup + "." + run.Name,
+		Time:      junitSeconds(0),
+		SystemOut: run.Verdict.String(),
+	}
+	if failed := run.Verdict.Failed(); len(failed) > 0 {
+		messages := []string{}
+		for _, result := range failed {
+			messages = append(messages, result.String())
+		}
+		testCase.Failure = &junitFailure{
+			Message: fmt.Sprintf("%v of %v thresholds failed", len(failed), len(run.Verdict.Results)),
+			Type:    "threshold",
+			Text:    strings.Join(messages, "\n"),
+		}
+	}
+	return testCase
+}
+
+func executionFailureText(execution *Execution) string {
+	return fmt.Sprintf("%v: %v (correlation id: %v)", execution.jobTitle, execution.err, execution.context.CorrelationId())
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
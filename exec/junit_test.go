@@ -0,0 +1,106 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newJUnitTestRepository(reporter Reporter) *Repository {
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("ok", newMockExec("ok"), newChannelFactory()), "group1", 1)
+	repo.MustAdd(NewTestScenario("failing", newMockErrorExecution("failing"), newChannelFactory()).
+		WithThresholds(MustThreshold("error_rate < 1%")), "group1", 1)
+	repo.MustAdd(NewTestScenario("other", newMockExec("other"), newChannelFactory()), "group2", 1)
+	return repo
+}
+
+func Test_JUnitReporter(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+
+	newJUnitTestRepository(NewJUnitWriterReporter(out)).RunTestScenarios("", "")
+
+	suites := &junitTestSuites{}
+	a.NoError(xml.Unmarshal(out.Bytes(), suites))
+	a.Equal(3, suites.Tests)
+	a.Equal(1, suites.Failures)
+	a.Equal(2, len(suites.Suites))
+
+	group1 := suites.Suites[0]
+	a.Equal("group1", group1.Name)
+	a.Equal(2, group1.Tests)
+	a.Equal(1, group1.Failures)
+	a.Equal("ok", group1.Cases[0].Name)
+	a.Nil(group1.Cases[0].Failure)
+	a.Contains(group1.Cases[0].SystemOut, "1 executions, 0 errors")
+
+	failure := group1.Cases[1].Failure
+	a.Equal("1 of 1 executions failed; threshold error_rate < 1%: observed 100.00%, failed", failure.Message)
+	a.Contains(failure.Text, `failing: Wanted error on "failing" (correlation id: `)
+
+	a.Equal("group2", suites.Suites[1].Name)
+	a.Equal("other", suites.Suites[1].Cases[0].Name)
+}
+
+func Test_JUnitReporter_PerExecution(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	reporter := NewJUnitWriterReporter(out)
+	reporter.PerExecution = true
+
+	newJUnitTestRepository(reporter).RunTestScenarios("group1", "")
+
+	suites := &junitTestSuites{}
+	a.NoError(xml.Unmarshal(out.Bytes(), suites))
+	a.Equal(1, len(suites.Suites))
+	cases := suites.Suites[0].Cases
+	a.Equal(3, len(cases))
+	a.Equal("#0 ok", cases[0].Name)
+	a.Equal("group1.ok", cases[0].Classname)
+	a.Equal("#0 failing", cases[1].Name)
+	a.Equal(`Wanted error on "failing"`, cases[1].Failure.Message)
+	a.Equal("thresholds", cases[2].Name)
+	a.Equal("1 of 1 thresholds failed", cases[2].Failure.Message)
+	a.Equal(2, suites.Failures)
+}
+
+func Test_JUnitReporter_File(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "junit")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "report.xml")
+	newJUnitTestRepository(NewJUnitReporter(path)).RunTestScenarios("group2", "")
+
+	content, err := ioutil.ReadFile(path)
+	a.NoError(err)
+	a.Contains(string(content), `<testcase name="other" classname="group2"`)
+
+	a.Error(NewJUnitReporter(filepath.Join(dir, "missing", "report.xml")).RunFinished())
+}
+
+func Test_JUnitReporter_LimitsFailures(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	reporter := NewJUnitWriterReporter(out)
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("failing", newMockErrorExecution("failing"), contextsFactory(25)), "group", 1)
+
+	repo.RunTestScenarios("", "")
+
+	suites := &junitTestSuites{}
+	a.NoError(xml.Unmarshal(out.Bytes(), suites))
+	failure := suites.Suites[0].Cases[0].Failure
+	a.Equal("25 of 25 executions failed", failure.Message)
+	a.Equal(junitMaxFailures+1, strings.Count(failure.Text, "\n")+1)
+	a.Contains(failure.Text, "... 15 more failed executions")
+}
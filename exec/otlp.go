@@ -0,0 +1,201 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// OTLPReporter exports each execution and its steps as OpenTelemetry spans
+// over OTLP/HTTP with JSON encoding, e.g. to http://localhost:4318/v1/traces.
+// The span ids are the ones propagated by the traceparent header of HttpExec,
+// so that the spans of the backend become children of the godriver spans.
+// The batches are exported in the background, RunFinished waits for them.
+type OTLPReporter struct {
+	// BatchSize is the maximum number of spans per request.
+	BatchSize int
+	// Client is the http client used for the export.
+	Client *http.Client
+
+	url         string
+	serviceName string
+	mutex       sync.Mutex
+	spans       []otlpSpan
+	sender      *batchSender
+}
+
+type otlpExport struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOk         = 1
+	otlpStatusError      = 2
+)
+
+// NewOTLPReporter creates a reporter, which exports the spans to the url
+// with the supplied service name as resource attribute.
+func NewOTLPReporter(url, serviceName string) *OTLPReporter {
+	reporter := &OTLPReporter{
+		BatchSize:   512,
+		Client:      http.DefaultClient,
+		url:         url,
+		serviceName: serviceName,
+	}
+	reporter.sender = newBatchSender(reporter.post)
+	return reporter
+}
+
+func (reporter *OTLPReporter) ScenarioStarted(run *ScenarioRun) {
+}
+
+func (reporter *OTLPReporter) Report(run *ScenarioRun, execution *Execution) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.addSpans(execution)
+}
+
+func (reporter *OTLPReporter) addSpans(execution *Execution) {
+	reporter.spans = append(reporter.spans, newOTLPSpan(execution))
+	if len(reporter.spans) >= reporter.BatchSize {
+		reporter.flush()
+	}
+	for _, step := range execution.steps {
+		reporter.addSpans(step)
+	}
+}
+
+func (reporter *OTLPReporter) ScenarioFinished(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.flush()
+}
+
+// RunFinished exports the remaining spans, waits for the exported batches
+// and returns the first export error since the last call.
+func (reporter *OTLPReporter) RunFinished() error {
+	reporter.mutex.Lock()
+	reporter.flush()
+	reporter.mutex.Unlock()
+	return reporter.sender.drain()
+}
+
+// flush queues the current spans for the export.
+func (reporter *OTLPReporter) flush() {
+	if len(reporter.spans) == 0 {
+		return
+	}
+	export := otlpExport{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{otlpAttr("service.name", reporter.serviceName)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/smancke/godriver"},
+				Spans: reporter.spans,
+			}},
+		}},
+	}
+	reporter.spans = nil
+
+	body, err := json.Marshal(export)
+	if err != nil {
+		reporter.sender.fail(err)
+		return
+	}
+	reporter.sender.send(body)
+}
+
+func (reporter *OTLPReporter) post(body []byte) error {
+	resp, err := reporter.Client.Post(reporter.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+func newOTLPSpan(execution *Execution) otlpSpan {
+	span := otlpSpan{
+		TraceId:           execution.TraceId(),
+		SpanId:            execution.SpanId(),
+		ParentSpanId:      execution.ParentSpanId(),
+		Name:              execution.name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(execution.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(execution.end.UnixNano(), 10),
+		Attributes: []otlpAttribute{
+			otlpAttr("godriver.test_group", execution.testGroup),
+			otlpAttr("godriver.scenario", execution.scenario),
+			otlpAttr("godriver.job_title", execution.jobTitle),
+		},
+		Status: otlpStatus{Code: otlpStatusOk},
+	}
+	if execution.context != nil {
+		span.Attributes = append(span.Attributes,
+			otlpAttr("godriver.correlation_id", execution.context.CorrelationId()),
+			otlpAttr("godriver.test_number", strconv.Itoa(execution.context.TestNumber())))
+	}
+	if execution.err != nil {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: execution.err.Error()}
+	}
+	return span
+}
+
+func otlpAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
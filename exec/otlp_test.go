@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_Execution_TraceContext(t *testing.T) {
+	a := assert.New(t)
+
+	var cntx Context = NewDefaultContext()
+	parent := StartExecution("parent", &cntx)
+	child := StartExecution("child", &cntx)
+
+	a.Regexp(`^[0-9a-f]{32}$`, parent.TraceId())
+	a.Regexp(`^[0-9a-f]{16}$`, parent.SpanId())
+	a.Equal("", parent.ParentSpanId())
+	a.Equal(parent.TraceId(), child.TraceId())
+	a.Equal(parent.SpanId(), child.ParentSpanId())
+	a.NotEqual(parent.SpanId(), child.SpanId())
+	a.Equal("00-"+child.TraceId()+"-"+child.SpanId()+"-01", child.TraceParent())
+
+	var other Context = NewDefaultContext()
+	a.NotEqual(parent.TraceId(), StartExecution("other", &other).TraceId())
+}
+
+func Test_OTLPReporter(t *testing.T) {
+	a := assert.New(t)
+
+	exports := []*otlpExport{}
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		a.Equal("/v1/traces", req.URL.Path)
+		a.Equal("application/json", req.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(req.Body)
+		export := &otlpExport{}
+		a.NoError(json.Unmarshal(body, export))
+		exports = append(exports, export)
+	}))
+	defer server.Close()
+
+	headers := make(chan http.Header, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+	}))
+	defer backend.Close()
+
+	reporter := NewOTLPReporter(server.URL+"/v1/traces", "loadtest")
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("spec",
+		Seq("seq",
+			Get(backend.URL).Named("backend call"),
+			newMockErrorExecution("failing")),
+		newChannelFactory()), "otlp", 1)
+	repo.RunTestScenarios("otlp", "")
+
+	a.Equal(1, len(exports))
+	resource := exports[0].ResourceSpans[0]
+	a.Equal(otlpAttr("service.name", "loadtest"), resource.Resource.Attributes[0])
+	spans := resource.ScopeSpans[0].Spans
+	a.Equal(3, len(spans))
+
+	root, call, failing := spans[0], spans[1], spans[2]
+	a.Equal("seq", root.Name)
+	a.Equal("", root.ParentSpanId)
+	a.Equal(otlpStatusError, root.Status.Code)
+	a.Equal("backend call", call.Name)
+	a.Equal(root.TraceId, call.TraceId)
+	a.Equal(root.SpanId, call.ParentSpanId)
+	a.Equal(otlpStatusOk, call.Status.Code)
+	a.Equal(`Wanted error on "failing"`, failing.Status.Message)
+	a.Contains(root.Attributes, otlpAttr("godriver.scenario", "spec"))
+	a.Regexp(regexp.MustCompile(`^\d+$`), root.StartTimeUnixNano)
+
+	// the backend got the span of the http call as parent
+	a.Equal("00-"+call.TraceId+"-"+call.SpanId+"-01", (<-headers).Get("traceparent"))
+}
+
+func Test_OTLPReporter_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(500)
+	}))
+	defer server.Close()
+
+	reporter := NewOTLPReporter(server.URL, "loadtest")
+	reporter.Report(nil, newTestExecution("spec", time.Now(), 0, errors.New("x")))
+	assert.EqualError(t, reporter.RunFinished(), "otlp export returned status 500")
+}
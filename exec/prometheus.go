@@ -0,0 +1,216 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPrometheusBuckets are the upper bounds of the duration histograms in seconds.
+var DefaultPrometheusBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusReporter publishes the executions of repository runs
+// as metrics in the Prometheus text format.
+// It implements http.Handler, so it can be served by Serve or any http server.
+//
+// The metrics are:
+//
+//	godriver_executions_total{test_group,scenario,status}
+//	godriver_execution_duration_seconds{test_group,scenario} (histogram)
+//	godriver_step_executions_total{test_group,scenario,step,status}
+//	godriver_step_duration_seconds{test_group,scenario,step} (histogram)
+//	godriver_active_workers{test_group,scenario}
+//	godriver_scenario_running{test_group,scenario}
+type PrometheusReporter struct {
+	buckets []float64
+
+	mutex      sync.Mutex
+	executions map[string]*prometheusSeries
+	steps      map[string]*prometheusSeries
+	runs       map[*ScenarioRun]bool
+	scenarios  map[string]bool
+}
+
+type prometheusSeries struct {
+	ok           uint64
+	errors       uint64
+	bucketCounts []uint64
+	sum          float64
+}
+
+// NewPrometheusReporter creates a reporter with the DefaultPrometheusBuckets.
+func NewPrometheusReporter() *PrometheusReporter {
+	return NewPrometheusReporterWithBuckets(DefaultPrometheusBuckets)
+}
+
+// NewPrometheusReporterWithBuckets creates a reporter with the supplied histogram buckets in seconds.
+func NewPrometheusReporterWithBuckets(buckets []float64) *PrometheusReporter {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	return &PrometheusReporter{
+		buckets:    sorted,
+		executions: make(map[string]*prometheusSeries),
+		steps:      make(map[string]*prometheusSeries),
+		runs:       make(map[*ScenarioRun]bool),
+		scenarios:  make(map[string]bool),
+	}
+}
+
+// Serve starts an http server on addr, which serves the metrics on /metrics.
+// The server should be closed after the run.
+func (reporter *PrometheusReporter) Serve(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reporter)
+	server := &http.Server{Addr: listener.Addr().String(), Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
+
+func (reporter *PrometheusReporter) ScenarioStarted(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.runs[run] = true
+	reporter.scenarios[prometheusLabels("test_group", run.TestGroup, "scenario", run.Name)] = true
+}
+
+func (reporter *PrometheusReporter) Report(run *ScenarioRun, execution *Execution) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	labels := prometheusLabels("test_group", run.TestGroup, "scenario", run.Name)
+	reporter.series(reporter.executions, labels).add(execution, reporter.buckets)
+	reporter.reportSteps(run, execution.steps)
+}
+
+func (reporter *PrometheusReporter) reportSteps(run *ScenarioRun, steps []*Execution) {
+	for _, step := range steps {
+		labels := prometheusLabels("test_group", run.TestGroup, "scenario", run.Name, "step", step.name)
+		reporter.series(reporter.steps, labels).add(step, reporter.buckets)
+		reporter.reportSteps(run, step.steps)
+	}
+}
+
+func (reporter *PrometheusReporter) ScenarioFinished(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	delete(reporter.runs, run)
+}
+
+func (reporter *PrometheusReporter) series(m map[string]*prometheusSeries, labels string) *prometheusSeries {
+	series, exists := m[labels]
+	if !exists {
+		series = &prometheusSeries{
+			bucketCounts: make([]uint64, len(reporter.buckets)),
+		}
+		m[labels] = series
+	}
+	return series
+}
+
+func (series *prometheusSeries) add(execution *Execution, buckets []float64) {
+	if execution.err != nil {
+		series.errors++
+	} else {
+		series.ok++
+	}
+	seconds := execution.Duration().Seconds()
+	series.sum += seconds
+	for i, bound := range buckets {
+		if seconds <= bound {
+			series.bucketCounts[i]++
+		}
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (reporter *PrometheusReporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(reporter.metrics())
+}
+
+func (reporter *PrometheusReporter) metrics() []byte {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	b := bytes.NewBuffer(nil)
+
+	reporter.writeCounter(b, "godriver_executions_total", "Number of scenario executions.", reporter.executions)
+	reporter.writeHistogram(b, "godriver_execution_duration_seconds", "Duration of the scenario executions.", reporter.executions)
+	reporter.writeCounter(b, "godriver_step_executions_total", "Number of step executions.", reporter.steps)
+	reporter.writeHistogram(b, "godriver_step_duration_seconds", "Duration of the step executions.", reporter.steps)
+
+	active := map[string]int{}
+	for run := range reporter.runs {
+		active[prometheusLabels("test_group", run.TestGroup, "scenario", run.Name)] += run.ActiveWorkers()
+	}
+	scenarios := []string{}
+	for labels := range reporter.scenarios {
+		scenarios = append(scenarios, labels)
+	}
+	sort.Strings(scenarios)
+	fmt.Fprintln(b, "# HELP godriver_active_workers Number of running workers.")
+	fmt.Fprintln(b, "# TYPE godriver_active_workers gauge")
+	for _, labels := range scenarios {
+		fmt.Fprintf(b, "godriver_active_workers{%v} %v\n", labels, active[labels])
+	}
+	fmt.Fprintln(b, "# HELP godriver_scenario_running Whether the scenario is running.")
+	fmt.Fprintln(b, "# TYPE godriver_scenario_running gauge")
+	for _, labels := range scenarios {
+		running := 0
+		if _, exists := active[labels]; exists {
+			running = 1
+		}
+		fmt.Fprintf(b, "godriver_scenario_running{%v} %v\n", labels, running)
+	}
+	return b.Bytes()
+}
+
+func (reporter *PrometheusReporter) writeCounter(b *bytes.Buffer, name, help string, m map[string]*prometheusSeries) {
+	fmt.Fprintf(b, "# HELP %v %v\n# TYPE %v counter\n", name, help, name)
+	for _, labels := range sortedLabels(m) {
+		series := m[labels]
+		fmt.Fprintf(b, "%v{%v,status=\"ok\"} %v\n", name, labels, series.ok)
+		fmt.Fprintf(b, "%v{%v,status=\"error\"} %v\n", name, labels, series.errors)
+	}
+}
+
+func (reporter *PrometheusReporter) writeHistogram(b *bytes.Buffer, name, help string, m map[string]*prometheusSeries) {
+	fmt.Fprintf(b, "# HELP %v %v\n# TYPE %v histogram\n", name, help, name)
+	for _, labels := range sortedLabels(m) {
+		series := m[labels]
+		for i, bound := range reporter.buckets {
+			fmt.Fprintf(b, "%v_bucket{%v,le=\"%v\"} %v\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), series.bucketCounts[i])
+		}
+		count := series.ok + series.errors
+		fmt.Fprintf(b, "%v_bucket{%v,le=\"+Inf\"} %v\n", name, labels, count)
+		fmt.Fprintf(b, "%v_sum{%v} %v\n", name, labels, strconv.FormatFloat(series.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%v_count{%v} %v\n", name, labels, count)
+	}
+}
+
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusLabels formats name value pairs as label list.
+func prometheusLabels(nameValues ...string) string {
+	labels := make([]string, 0, len(nameValues)/2)
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		labels = append(labels, nameValues[i]+`="`+prometheusEscaper.Replace(nameValues[i+1])+`"`)
+	}
+	return strings.Join(labels, ",")
+}
+
+func sortedLabels(m map[string]*prometheusSeries) []string {
+	labels := make([]string, 0, len(m))
+	for k := range m {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	return labels
+}
@@ -0,0 +1,65 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_PrometheusReporter(t *testing.T) {
+	a := assert.New(t)
+	reporter := NewPrometheusReporterWithBuckets([]float64{0.1, 0.01})
+
+	run := &ScenarioRun{Name: "spec", TestGroup: "group \"1\"", Start: time.Now(), Stats: NewStats()}
+	reporter.ScenarioStarted(run)
+	reporter.Report(run, newTestExecution("spec", time.Now(), 5*time.Millisecond, nil,
+		newTestExecution("login", time.Now(), 50*time.Millisecond, nil)))
+	reporter.Report(run, newTestExecution("spec", time.Now(), 500*time.Millisecond, errors.New("failed"),
+		newTestExecution("login", time.Now(), 500*time.Millisecond, errors.New("failed"))))
+
+	metrics := string(reporter.metrics())
+	labels := `test_group="group \"1\"",scenario="spec"`
+	a.Contains(metrics, "# TYPE godriver_executions_total counter\n"+
+		`godriver_executions_total{`+labels+`,status="ok"} 1`+"\n"+
+		`godriver_executions_total{`+labels+`,status="error"} 1`+"\n")
+	a.Contains(metrics, "# TYPE godriver_execution_duration_seconds histogram\n"+
+		`godriver_execution_duration_seconds_bucket{`+labels+`,le="0.01"} 1`+"\n"+
+		`godriver_execution_duration_seconds_bucket{`+labels+`,le="0.1"} 1`+"\n"+
+		`godriver_execution_duration_seconds_bucket{`+labels+`,le="+Inf"} 2`+"\n"+
+		`godriver_execution_duration_seconds_sum{`+labels+`} 0.505`+"\n"+
+		`godriver_execution_duration_seconds_count{`+labels+`} 2`+"\n")
+	a.Contains(metrics, `godriver_step_executions_total{`+labels+`,step="login",status="error"} 1`)
+	a.Contains(metrics, `godriver_step_duration_seconds_bucket{`+labels+`,step="login",le="0.1"} 1`)
+	a.Contains(metrics, `godriver_active_workers{`+labels+`} 0`)
+	a.Contains(metrics, `godriver_scenario_running{`+labels+`} 1`)
+
+	reporter.ScenarioFinished(run)
+	a.Contains(string(reporter.metrics()), `godriver_scenario_running{`+labels+`} 0`)
+}
+
+func Test_PrometheusReporter_Serve(t *testing.T) {
+	a := assert.New(t)
+	reporter := NewPrometheusReporter()
+
+	server, err := reporter.Serve("127.0.0.1:0")
+	a.NoError(err)
+	defer server.Close()
+
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("spec", newMockExec("spec"), newChannelFactory()), "prometheus", 1)
+	repo.RunTestScenarios("prometheus", "")
+
+	resp, err := http.Get("http://" + server.Addr + "/metrics")
+	a.NoError(err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	a.Equal("text/plain; version=0.0.4; charset=utf-8", resp.Header.Get("Content-Type"))
+	a.Contains(string(body), `godriver_executions_total{test_group="prometheus",scenario="spec",status="ok"} 1`)
+
+	_, err = reporter.Serve("invalid address")
+	a.Error(err)
+}
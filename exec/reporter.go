@@ -0,0 +1,139 @@
+package exec
+
+import (
+	"sync"
+	"time"
+)
+
+// Reporter is notified about the progress of a repository run.
+type Reporter interface {
+	// ScenarioStarted is called before the first execution of a scenario.
+	ScenarioStarted(run *ScenarioRun)
+
+	// Report is called for each execution, as it is returned from RunParallel.
+	// The execution is already aggregated in the stats of the run.
+	Report(run *ScenarioRun, execution *Execution)
+
+	// ScenarioFinished is called after the last execution of a scenario.
+	ScenarioFinished(run *ScenarioRun)
+}
+
+// RunFinisher is implemented by reporters, which have to be notified
+// at the end of RunTestScenarios, e.g. to write a report file.
+type RunFinisher interface {
+	RunFinished() error
+}
+
+// SkipReporter is implemented by reporters, which are notified about scenarios,
+// which were not run, e.g. because a dependency failed.
+// For skipped scenarios, ScenarioSkipped is called instead of ScenarioStarted and ScenarioFinished.
+type SkipReporter interface {
+	ScenarioSkipped(run *ScenarioRun)
+}
+
+// SummaryReporter is implemented by reporters, which are notified about the summary
+// of all scenarios, if the scenarios run concurrently. RunSummary is called after
+// the last scenario finished and before RunFinished.
+type SummaryReporter interface {
+	RunSummary(summary *ScenarioRun)
+}
+
+// ScenarioRun describes the run of a single test scenario.
+type ScenarioRun struct {
+	Name        string
+	TestGroup   string
+	Tags        []string
+	Concurrency int
+	// ExpectedExecutions is the number of executions, if known by the scenario.
+	ExpectedExecutions int
+	Start              time.Time
+	Stats              *Stats
+	// Verdict is the evaluation of the thresholds, which is set before ScenarioFinished is called.
+	Verdict *Verdict
+	// AbortedBy is the threshold result, which aborted the run during the execution, or nil.
+	AbortedBy *ThresholdResult
+	// SkipReason is set, if the scenario was not run, e.g. because a dependency failed.
+	SkipReason string
+	// mutex guards the executor and the end, which are read by reporters during the run.
+	mutex    sync.Mutex
+	executor *parallelExecutor
+	end      time.Time
+}
+
+func newScenarioRun(entry *repositoryEntry) *ScenarioRun {
+	return &ScenarioRun{
+		Name:               entry.testScenario.Name,
+		TestGroup:          entry.testGroup,
+		Tags:               entry.tags,
+		Concurrency:        entry.concurrency,
+		ExpectedExecutions: entry.testScenario.ExpectedExecutions,
+		Start:              time.Now(),
+		Stats:              NewStats(),
+	}
+}
+
+// Skipped returns true, if the scenario was not run.
+func (run *ScenarioRun) Skipped() bool {
+	return run.SkipReason != ""
+}
+
+// Passed returns true, if the scenario was run without failed executions and thresholds.
+func (run *ScenarioRun) Passed() bool {
+	return !run.Skipped() && run.Stats.Total().Errors == 0 && (run.Verdict == nil || run.Verdict.Passed())
+}
+
+// ActiveWorkers returns the number of workers, which are still running.
+func (run *ScenarioRun) ActiveWorkers() int {
+	run.mutex.Lock()
+	executor := run.executor
+	run.mutex.Unlock()
+	if executor == nil {
+		return 0
+	}
+	return executor.activeWorkers()
+}
+
+// setExecutor sets the executor, after the reporters were notified about the start.
+func (run *ScenarioRun) setExecutor(executor *parallelExecutor) {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.executor = executor
+}
+
+// EndTime returns the end of the run, or the zero time while it is running.
+func (run *ScenarioRun) EndTime() time.Time {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	return run.end
+}
+
+func (run *ScenarioRun) finish() {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.end = time.Now()
+}
+
+// Elapsed returns the duration of the run until now, or until its end.
+func (run *ScenarioRun) Elapsed() time.Duration {
+	if end := run.EndTime(); !end.IsZero() {
+		return end.Sub(run.Start)
+	}
+	return time.Since(run.Start)
+}
+
+// Remaining estimates the remaining duration of the run, based on the
+// executions done so far. It returns false, if no estimation is possible.
+func (run *ScenarioRun) Remaining() (time.Duration, bool) {
+	if !run.EndTime().IsZero() {
+		return 0, true
+	}
+	done := run.Stats.Total().Count
+	if run.ExpectedExecutions <= 0 || done == 0 {
+		return 0, false
+	}
+	if done >= run.ExpectedExecutions {
+		return 0, true
+	}
+	elapsed := run.Elapsed()
+	return time.Duration(float64(elapsed) / float64(done) * float64(run.ExpectedExecutions-done)), true
+}
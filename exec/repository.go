@@ -1,13 +1,31 @@
 package exec
 
 import (
-	"regexp"
+	"fmt"
+	"os"
+	"sync"
+	"time"
 )
 
 // A repository is a set of test groups with tests.
 type Repository struct {
 	testScenarios []*repositoryEntry
-	runResults    []*repositoryRunResult
+	reporters     []Reporter
+	correlation   *CorrelationConfig
+	concurrency   int
+	env           map[string]string
+	groupHooks    map[string]*Hooks
+	concurrent    bool
+	secrets       []string
+	shared        *SharedStore
+	// runMutex serializes overlapping runs, because the reporters collect the results of one run at a time.
+	runMutex sync.Mutex
+	// mutex guards the state of the run, which is accessed by Abort and LastReport.
+	mutex      sync.Mutex
+	lastReport *RunReport
+	abort      chan struct{}
+	// reportMutex serializes the calls of the reporters by concurrent scenarios.
+	reportMutex sync.Mutex
 }
 
 type repositoryEntry struct {
@@ -17,21 +35,131 @@ type repositoryEntry struct {
 	testScenario *TestScenario
 }
 
-type repositoryRunResult struct {
-	scenario   *repositoryEntry
-	executions []*Execution
+// ScenarioInfo describes a scenario of the repository.
+type ScenarioInfo struct {
+	Name        string
+	TestGroup   string
+	Tags        []string
+	Concurrency int
+	DependsOn   []string
+	// Dependency is true, if the scenario does not match the filter,
+	// but is run as dependency of a selected scenario.
+	Dependency bool
 }
 
+// runState is the state of a single RunTestScenarios, which is shared by its scenarios.
+type runState struct {
+	abort       chan struct{}
+	groups      map[string]*groupRun
+	skipReasons map[*repositoryEntry]string
+	summary     *ScenarioRun
+	reportMutex *sync.Mutex
+	runsMutex   sync.Mutex
+	runs        map[*repositoryEntry]*ScenarioRun
+}
+
+// AbortedSkipReason is the skip reason of the scenarios, which were not started, because the run was aborted.
+const AbortedSkipReason = "run aborted"
+
+// thresholdWatchInterval is the interval, in which thresholds are evaluated during a run.
+var thresholdWatchInterval = 100 * time.Millisecond
+
 // TestFactory is a factory method which returns a test with its data.
 type TestFactory func(Context) (Exec, chan Context)
 
+// NewRepository creates an empty repository,
+// which reports to the console.
 func NewRepository() *Repository {
 	return &Repository{
 		testScenarios: make([]*repositoryEntry, 0, 0),
+		reporters:     []Reporter{NewConsoleReporter(os.Stdout)},
+		shared:        NewSharedStore(),
 	}
 }
 
-func (repo *Repository) Add(scenario *TestScenario, testGroup string, concurrency int, tags ...string) {
+// SetReporters replaces the reporters of the repository.
+// Calling it without arguments disables the reporting.
+func (repo *Repository) SetReporters(reporters ...Reporter) {
+	repo.reporters = reporters
+}
+
+// Reporters returns the reporters of the repository.
+func (repo *Repository) Reporters() []Reporter {
+	return append([]Reporter{}, repo.reporters...)
+}
+
+// SetCorrelation sets the correlation configuration for the contexts of all
+// scenarios, which do not have an own one.
+func (repo *Repository) SetCorrelation(config *CorrelationConfig) {
+	repo.correlation = config
+}
+
+// SetEnv sets env values, which override the env of the contexts of all scenarios,
+// e.g. the env of a profile loaded by an EnvLoader.
+func (repo *Repository) SetEnv(env map[string]string) {
+	repo.env = env
+}
+
+// Env returns a copy of the env values set by SetEnv.
+func (repo *Repository) Env() map[string]string {
+	return copyStringMap(repo.env)
+}
+
+// SetGroupHooks sets the hooks of all scenarios of the test group.
+// See Hooks for the order, in which the hooks of groups and scenarios run.
+func (repo *Repository) SetGroupHooks(testGroup string, hooks Hooks) {
+	if repo.groupHooks == nil {
+		repo.groupHooks = map[string]*Hooks{}
+	}
+	repo.groupHooks[testGroup] = &hooks
+}
+
+// MarkSecret marks keys of the env and test data as secret for the contexts of the scenarios
+// and hooks of the repository, see the global MarkSecret for the redaction.
+func (repo *Repository) MarkSecret(keys ...string) {
+	repo.secrets = append(repo.secrets, keys...)
+}
+
+// SetShared replaces the store for the shared state of the repository.
+func (repo *Repository) SetShared(store *SharedStore) {
+	repo.shared = store
+}
+
+// Shared returns the store for the shared state of the repository. The contexts of
+// its scenarios and hooks use it, unless they were created with an own store by WithShared.
+// Each repository has its own store, so that the counters, queues and pools of
+// separate repositories do not interfere.
+func (repo *Repository) Shared() *SharedStore {
+	return repo.shared
+}
+
+// SetConcurrentScenarios runs the selected scenarios of RunTestScenarios at the same time,
+// each with its own workers, instead of one after another, e.g. to combine browsing users,
+// API clients and admin jobs. A scenario still starts after its dependencies.
+// The reporters get the executions of all scenarios as one stream, but are not called concurrently.
+// Reporters, which implement SummaryReporter, get the summary of all scenarios at the end.
+func (repo *Repository) SetConcurrentScenarios(concurrent bool) {
+	repo.concurrent = concurrent
+}
+
+// OverrideConcurrency runs all scenarios with the supplied number of workers,
+// instead of their own concurrency. Zero disables the override.
+func (repo *Repository) OverrideConcurrency(concurrency int) {
+	repo.concurrency = concurrency
+}
+
+// AddReporter adds a reporter to the repository.
+func (repo *Repository) AddReporter(reporter Reporter) {
+	repo.reporters = append(repo.reporters, reporter)
+}
+
+// Add registers the scenario. The steps of the scenario are validated,
+// so that e.g. invalid templates are reported here instead of during the run.
+// An invalid scenario is not added.
+func (repo *Repository) Add(scenario *TestScenario, testGroup string, concurrency int, tags ...string) error {
+	if err := Validate(scenario.Exec); err != nil {
+		return fmt.Errorf("scenario %q: %v", scenario.Name, err)
+	}
 	repo.testScenarios = append(repo.testScenarios,
 		&repositoryEntry{
 			testGroup:    testGroup,
@@ -39,67 +167,504 @@ func (repo *Repository) Add(scenario *TestScenario, testGroup string, concurrenc
 			tags:         tags,
 			testScenario: scenario,
 		})
+	return nil
 }
 
-// Run all testScenarios, which match the supplied filter criteria.
-func (repo *Repository) RunTestScenarios(testGroupRegex string, nameRegex string, tagPatterns ...string) {
-	runResults := make([]*repositoryRunResult, 0, 0)
-	for _, t := range repo.testScenarios {
-		if matched, err := regexp.MatchString(nameRegex, t.testScenario.Name); err == nil && matched {
-			if matched, err := regexp.MatchString(testGroupRegex, t.testGroup); err == nil && matched {
-				if allTagsContained(t.tags, tagPatterns) {
-					executions := t.runTestScenario()
-					runResults = append(runResults, &repositoryRunResult{t, executions})
+// MustAdd is like Add, but panics if the scenario is invalid,
+// so that a typo in a template does not silently drop the scenario.
+func (repo *Repository) MustAdd(scenario *TestScenario, testGroup string, concurrency int, tags ...string) {
+	if err := repo.Add(scenario, testGroup, concurrency, tags...); err != nil {
+		panic(err)
+	}
+}
+
+// Run all testScenarios, which match the supplied filter criteria, and their dependencies.
+// A scenario runs after its dependencies and is skipped, if one of them failed.
+// The returned report contains the results of the scenarios. An invalid regular expression
+// or tag pattern is returned as error, without running a scenario. The repository may be called
+// from several goroutines, but has to be configured before. Overlapping runs are serialized,
+// so that a run waits until the running one finished, because the reporters collect
+// the results of one run at a time.
+func (repo *Repository) RunTestScenarios(testGroupRegex string, nameRegex string, tagPatterns ...string) (*RunReport, error) {
+	return repo.RunFiltered(Filter{Group: testGroupRegex, Name: nameRegex, Tags: tagPatterns})
+}
+
+// RunFiltered runs the scenarios, which match the filter, and their dependencies like RunTestScenarios.
+// An invalid filter is returned as error, without running a scenario.
+func (repo *Repository) RunFiltered(filter Filter) (*RunReport, error) {
+	selected, err := repo.selectScenarios(filter)
+	if err != nil {
+		return nil, err
+	}
+	return repo.runScenarios(selected), nil
+}
+
+func (repo *Repository) runScenarios(matching []*repositoryEntry) *RunReport {
+	repo.runMutex.Lock()
+	defer repo.runMutex.Unlock()
+	selected, skipReasons := repo.scheduleScenarios(matching)
+	report := &RunReport{Start: time.Now()}
+	state := &runState{
+		abort:       repo.startRun(),
+		groups:      repo.groupRuns(selected),
+		skipReasons: skipReasons,
+		reportMutex: &repo.reportMutex,
+		runs:        map[*repositoryEntry]*ScenarioRun{},
+	}
+	defer repo.finishRun()
+	if repo.concurrent {
+		state.summary = &ScenarioRun{Name: "all scenarios", TestGroup: "summary", Start: report.Start, Stats: NewStats()}
+	}
+
+	results := make([]*ScenarioResult, len(selected))
+	if repo.concurrent {
+		repo.runConcurrently(selected, state, results)
+	} else {
+		for i, t := range selected {
+			results[i] = repo.runScheduled(t, state)
+		}
+	}
+	report.Scenarios = results
+	report.Summary = state.summary
+	report.Aborted = isClosed(state.abort)
+	report.End = time.Now()
+
+	repo.reportMutex.Lock()
+	if state.summary != nil {
+		for _, result := range report.Scenarios {
+			state.summary.Concurrency += result.Run.Concurrency
+		}
+		state.summary.finish()
+		for _, reporter := range repo.reporters {
+			if summarizer, ok := reporter.(SummaryReporter); ok {
+				summarizer.RunSummary(state.summary)
+			}
+		}
+	}
+	for _, reporter := range repo.reporters {
+		if finisher, ok := reporter.(RunFinisher); ok {
+			if err := finisher.RunFinished(); err != nil {
+				fmt.Fprintf(os.Stderr, "error on finishing report: %v\n", err)
+			}
+		}
+	}
+	repo.reportMutex.Unlock()
+
+	repo.mutex.Lock()
+	repo.lastReport = report
+	repo.mutex.Unlock()
+	return report
+}
+
+// runConcurrently starts each scenario, as soon as its dependencies are done,
+// and waits for all of them. The results are stored in the order of the scenarios.
+func (repo *Repository) runConcurrently(selected []*repositoryEntry, state *runState, results []*ScenarioResult) {
+	done := make(map[*repositoryEntry]chan struct{}, len(selected))
+	for _, t := range selected {
+		done[t] = make(chan struct{})
+	}
+	wg := sync.WaitGroup{}
+	for i, t := range selected {
+		wg.Add(1)
+		go func(i int, t *repositoryEntry) {
+			defer wg.Done()
+			defer close(done[t])
+			// scenarios with invalid dependencies are skipped without waiting, which also breaks cycles
+			if state.skipReasons[t] == "" {
+				for _, name := range t.testScenario.Dependencies {
+					for _, dependency := range repo.scenariosNamed(name) {
+						<-done[dependency]
+					}
 				}
 			}
+			results[i] = repo.runScheduled(t, state)
+		}(i, t)
+	}
+	wg.Wait()
+}
+
+// runScheduled runs the scenario, or skips it, if the run was aborted
+// or its dependencies are invalid or did not pass.
+func (repo *Repository) runScheduled(t *repositoryEntry, state *runState) *ScenarioResult {
+	reason := state.skipReasons[t]
+	if isClosed(state.abort) {
+		reason = AbortedSkipReason
+	}
+	if reason == "" {
+		reason = repo.dependencySkipReason(t, state)
+	}
+	var result *ScenarioResult
+	if reason != "" {
+		result = repo.skipTestScenario(t, state, reason)
+	} else {
+		result = repo.runTestScenario(t, state)
+	}
+	state.runsMutex.Lock()
+	state.runs[t] = result.Run
+	state.runsMutex.Unlock()
+	return result
+}
+
+// Summary returns the summary of all scenarios of the last run, if it ran them concurrently, otherwise nil.
+// Its stats aggregate the executions of all scenarios.
+func (repo *Repository) Summary() *ScenarioRun {
+	if report := repo.LastReport(); report != nil {
+		return report.Summary
+	}
+	return nil
+}
+
+// LastReport returns the report of the last finished run, or nil, if nothing was run yet.
+func (repo *Repository) LastReport() *RunReport {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.lastReport
+}
+
+// Abort stops the running RunTestScenarios, but not the runs waiting for it.
+// The running scenarios finish their current executions and the remaining scenarios
+// are skipped with the AbortedSkipReason, but the teardown hooks still run.
+// It may be called from any goroutine, e.g. on an interrupt signal.
+func (repo *Repository) Abort() {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.abort != nil && !isClosed(repo.abort) {
+		close(repo.abort)
+	}
+}
+
+// startRun returns the channel, which is closed by Abort during the run.
+func (repo *Repository) startRun() chan struct{} {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.abort = make(chan struct{})
+	return repo.abort
+}
+
+// finishRun releases the abort channel of the run.
+func (repo *Repository) finishRun() {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.abort = nil
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// groupRuns returns the state of the hooks for each test group of the selected scenarios.
+func (repo *Repository) groupRuns(selected []*repositoryEntry) map[string]*groupRun {
+	groups := map[string]*groupRun{}
+	for _, t := range selected {
+		group, exists := groups[t.testGroup]
+		if !exists {
+			group = &groupRun{hooks: &Hooks{}}
+			if hooks, exists := repo.groupHooks[t.testGroup]; exists {
+				group.hooks = hooks
+			}
+			groups[t.testGroup] = group
+		}
+		group.remaining++
+	}
+	return groups
+}
+
+// Scenarios returns the scenarios, which match the supplied filter criteria
+// in the same way as RunTestScenarios, in the order of their registration.
+func (repo *Repository) Scenarios(testGroupRegex string, nameRegex string, tagPatterns ...string) []ScenarioInfo {
+	selected, _ := repo.selectScenarios(Filter{Group: testGroupRegex, Name: nameRegex, Tags: tagPatterns})
+	infos := []ScenarioInfo{}
+	for _, t := range selected {
+		infos = append(infos, t.info())
+	}
+	return infos
+}
+
+// Select previews the scenarios, which RunFiltered runs for the filter, in the order of the run.
+// It includes the dependencies of the matching scenarios.
+func (repo *Repository) Select(filter Filter) ([]ScenarioInfo, error) {
+	matching, err := repo.selectScenarios(filter)
+	if err != nil {
+		return nil, err
+	}
+	isMatching := map[*repositoryEntry]bool{}
+	for _, t := range matching {
+		isMatching[t] = true
+	}
+	scheduled, _ := repo.scheduleScenarios(matching)
+	infos := []ScenarioInfo{}
+	for _, t := range scheduled {
+		info := t.info()
+		info.Dependency = !isMatching[t]
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (repo *Repository) selectScenarios(filter Filter) ([]*repositoryEntry, error) {
+	compiled, err := filter.compile()
+	if err != nil {
+		return nil, err
+	}
+	selected := []*repositoryEntry{}
+	for _, t := range repo.testScenarios {
+		if compiled.matches(t) {
+			selected = append(selected, t)
 		}
 	}
-	repo.runResults = runResults
+	return selected, nil
 }
 
+func (t *repositoryEntry) info() ScenarioInfo {
+	return ScenarioInfo{
+		Name:        t.testScenario.Name,
+		TestGroup:   t.testGroup,
+		Tags:        t.tags,
+		Concurrency: t.concurrency,
+		DependsOn:   t.testScenario.Dependencies,
+	}
+}
+
+// GetErrorExecutions returns the failed executions of all scenarios of the last run.
 func (repo *Repository) GetErrorExecutions() []*Execution {
-	if repo.runResults == nil {
+	report := repo.LastReport()
+	if report == nil {
+		return nil
+	}
+	return report.ErrorExecutions()
+}
+
+// GetFailedThresholds returns the failed thresholds of the last run.
+func (repo *Repository) GetFailedThresholds() []ThresholdResult {
+	report := repo.LastReport()
+	if report == nil {
 		return nil
 	}
+	return report.FailedThresholds()
+}
+
+// contextMapper returns the function, which prepares each context of the scenario
+// according to the repository configuration and the env of the setup hooks,
+// or nil if nothing has to be done.
+func (repo *Repository) contextMapper(t *repositoryEntry, hookEnv map[string]string) func(Context) Context {
+	correlation := t.testScenario.Correlation
+	if correlation == nil {
+		correlation = repo.correlation
+	}
+	env := repo.env
+	if len(hookEnv) > 0 {
+		env = copyStringMap(repo.env)
+		for k, v := range hookEnv {
+			env[k] = v
+		}
+	}
+	secrets := repo.secrets
+	if correlation == nil && len(env) == 0 && len(secrets) == 0 {
+		return nil
+	}
+	return func(cntx Context) Context {
+		if len(env) > 0 {
+			cntx = withEnv(cntx, env)
+		}
+		if len(secrets) > 0 {
+			cntx = withSecrets(cntx, secrets...)
+		}
+		if correlation != nil {
+			cntx = withCorrelation(cntx, correlation)
+		}
+		return cntx
+	}
+}
 
-	errorExecs := []*Execution{}
-	for _, result := range repo.runResults {
-		errorExecs = append(result.getErrorExecutions())
+// hookContext returns the context for the hooks of a test group with the env, secrets and store of the repository.
+func (repo *Repository) hookContext() Context {
+	cntx := NewContext(copyStringMap(repo.env)).WithSecrets(repo.secrets...)
+	if repo.shared != nil {
+		cntx = withShared(cntx, repo.shared)
 	}
-	return errorExecs
+	return cntx
 }
 
-func (t *repositoryEntry) runTestScenario() []*Execution {
+// mapContexts applies f to each context of the channel.
+func mapContexts(contexts chan Context, f func(Context) Context) chan Context {
+	mapped := make(chan Context)
+	go func() {
+		for cntx := range contexts {
+			mapped <- f(cntx)
+		}
+		close(mapped)
+	}()
+	return mapped
+}
+
+// runTestScenario runs the scenario between its hooks and the hooks of its test group.
+// Failures of the BeforeAll and AfterAll hooks are reported as failed executions.
+func (repo *Repository) runTestScenario(t *repositoryEntry, state *runState) *ScenarioResult {
+	group := state.groups[t.testGroup]
+	run := newScenarioRun(t)
+	if repo.concurrency > 0 {
+		run.Concurrency = repo.concurrency
+	}
+	state.reportMutex.Lock()
+	for _, reporter := range repo.reporters {
+		reporter.ScenarioStarted(run)
+	}
+	state.reportMutex.Unlock()
+
 	executions := []*Execution{}
-	results := RunParallel(t.concurrency, t.testScenario.Exec, t.testScenario.ContextChannelFactory())
-	for result := range results {
+	report := func(result *Execution) {
+		result.setScenario(run.Name, run.TestGroup)
+		run.Stats.Add(result)
+		if state.summary != nil {
+			state.summary.Stats.Add(result)
+		}
 		executions = append(executions, result)
-		println(result.String())
+		state.reportMutex.Lock()
+		defer state.reportMutex.Unlock()
+		for _, reporter := range repo.reporters {
+			reporter.Report(run, result)
+		}
+	}
+
+	if cntx, err := group.setUp(repo.hookContext()); err != nil {
+		report(hookExecution(cntx, "group before all", err))
+	} else {
+		repo.runExecutions(t, run, group, cntx, state.abort, report)
+	}
+	if err := group.done(); err != nil {
+		report(hookExecution(group.cntx, "group after all", err))
+	}
+
+	run.finish()
+	run.Verdict = EvaluateThresholds(run.Stats, t.testScenario.Thresholds)
+	state.reportMutex.Lock()
+	defer state.reportMutex.Unlock()
+	for _, reporter := range repo.reporters {
+		reporter.ScenarioFinished(run)
+	}
+	return &ScenarioResult{run, executions}
+}
+
+// runExecutions runs the executions of the scenario between its BeforeAll and AfterAll hooks.
+// The context contains the env of the setup hooks of the group.
+func (repo *Repository) runExecutions(t *repositoryEntry, run *ScenarioRun, group *groupRun, cntx Context, abort chan struct{}, report func(*Execution)) {
+	hooks := &t.testScenario.Hooks
+	hookEnv := copyStringMap(group.env)
+	cntx, err := setUp(cntx, hooks.BeforeAll, hookEnv)
+	defer func() {
+		if err := tearDown(cntx, hooks.AfterAll); err != nil {
+			report(hookExecution(cntx, "after all", err))
+		}
+	}()
+	if err != nil {
+		report(hookExecution(cntx, "before all", err))
+		return
+	}
+
+	contexts := t.testScenario.ContextChannelFactory()
+	if mapper := repo.contextMapper(t, hookEnv); mapper != nil {
+		contexts = mapContexts(contexts, mapper)
+	}
+	executor := newParallelExecutor(t.testScenario.Exec, contexts)
+	executor.beforeEach = append(append([]SetupHook{}, group.hooks.BeforeEach...), hooks.BeforeEach...)
+	executor.afterEach = append(append([]TeardownHook{}, hooks.AfterEach...), group.hooks.AfterEach...)
+	executor.shared = repo.shared
+	run.setExecutor(executor)
+	executor.start(run.Concurrency)
+	go executor.waitAndClose()
+	stopWatching := t.watchThresholds(run, executor)
+	defer stopWatching()
+	stopAbortWatching := watchAbort(executor, abort)
+	defer stopAbortWatching()
+
+	for result := range executor.results {
+		report(result)
 	}
-	return executions
 }
 
-func (r *repositoryRunResult) getErrorExecutions() []*Execution {
-	errorExecs := []*Execution{}
-	for _, exec := range r.executions {
-		if exec.err != nil {
-			errorExecs = append(errorExecs, exec)
+// skipTestScenario reports the scenario as skipped with the reason.
+func (repo *Repository) skipTestScenario(t *repositoryEntry, state *runState, reason string) *ScenarioResult {
+	run := newScenarioRun(t)
+	run.SkipReason = reason
+	run.Verdict = &Verdict{}
+	run.finish()
+	state.reportMutex.Lock()
+	for _, reporter := range repo.reporters {
+		if skipper, ok := reporter.(SkipReporter); ok {
+			skipper.ScenarioSkipped(run)
 		}
 	}
-	return errorExecs
+	state.reportMutex.Unlock()
+	if err := state.groups[t.testGroup].done(); err != nil {
+		fmt.Fprintf(os.Stderr, "error on tearing down test group %q: %v\n", t.testGroup, err)
+	}
+	return &ScenarioResult{Run: run}
 }
 
-func allTagsContained(tags []string, tagRegex []string) bool {
-	for _, tRegex := range tagRegex {
-		matchesOneTag := false
-		for _, t := range tags {
-			if matched, err := regexp.MatchString(tRegex, t); err == nil && matched {
-				matchesOneTag = true
-			}
+// watchAbort stops the executor, if the abort channel is closed.
+// The returned function stops the watching and has to be called after the run.
+func watchAbort(executor *parallelExecutor, abort chan struct{}) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-abort:
+			executor.stop()
+		case <-stop:
 		}
-		if !matchesOneTag {
-			return false
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// watchThresholds evaluates the thresholds with AbortAfter set during the run
+// and aborts the run, if one of them fails.
+// The returned function stops the watching and has to be called after the run.
+func (t *repositoryEntry) watchThresholds(run *ScenarioRun, executor *parallelExecutor) func() {
+	watched := []*Threshold{}
+	for _, threshold := range t.testScenario.Thresholds {
+		if threshold.AbortAfter > 0 {
+			watched = append(watched, threshold)
+		}
+	}
+	if len(watched) == 0 {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(thresholdWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for _, threshold := range watched {
+					if run.Elapsed() < threshold.AbortAfter {
+						continue
+					}
+					if result := threshold.Evaluate(run.Stats); !result.Passed && !result.NoData {
+						run.AbortedBy = &result
+						executor.stop()
+						return
+					}
+				}
+			}
 		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
 	}
-	return true
 }
@@ -3,7 +3,9 @@ package exec
 import (
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
+	"time"
 )
 
 var mockResult = ""
@@ -38,13 +40,13 @@ func Test_Repository(t *testing.T) {
 	a := assert.New(t)
 
 	repo := NewRepository()
-	repo.Add(NewTestScenario("spec11", newMockExec("spec11"), newChannelFactory()),
+	repo.MustAdd(NewTestScenario("spec11", newMockExec("spec11"), newChannelFactory()),
 		"group1", 1, "foo", "bar")
 
-	repo.Add(NewTestScenario("spec12", newMockExec("spec12"), newChannelFactory()),
+	repo.MustAdd(NewTestScenario("spec12", newMockExec("spec12"), newChannelFactory()),
 		"group1", 1, "foo", "bazz")
 
-	repo.Add(NewTestScenario("spec21", newMockExec("spec21"), newChannelFactory()),
+	repo.MustAdd(NewTestScenario("spec21", newMockExec("spec21"), newChannelFactory()),
 		"group2", 1)
 
 	mockResult = ""
@@ -83,7 +85,7 @@ func Test_ErrorExecutions_Nil(t *testing.T) {
 
 func Test_ErrorExecutions_Empty(t *testing.T) {
 	repo := NewRepository()
-	repo.Add(NewTestScenario("", newMockExec("Test_ErrorExecutionsEmpty"), newChannelFactory()), "groupppp", 0)
+	repo.MustAdd(NewTestScenario("", newMockExec("Test_ErrorExecutionsEmpty"), newChannelFactory()), "groupppp", 0)
 	repo.RunTestScenarios("groupppp", "")
 
 	assert.Empty(t, repo.GetErrorExecutions())
@@ -91,9 +93,226 @@ func Test_ErrorExecutions_Empty(t *testing.T) {
 
 func Test_ErrorExecutions_NotEmpty(t *testing.T) {
 	repo := NewRepository()
-	repo.Add(NewTestScenario("", newMockErrorExecution("Test_ErrorExecutionsNotEmpty"),
+	repo.MustAdd(NewTestScenario("", newMockErrorExecution("Test_ErrorExecutionsNotEmpty"),
 		newChannelFactory()), "ggggroup", 0)
 	repo.RunTestScenarios("ggggroup", "")
 
 	assert.NotEmpty(t, repo.GetErrorExecutions())
 }
+
+func Test_ErrorExecutions_AllScenarios(t *testing.T) {
+	a := assert.New(t)
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("first", newMockErrorExecution("first"), newChannelFactory()), "errors", 1)
+	repo.MustAdd(NewTestScenario("second", newMockErrorExecution("second"), newChannelFactory()), "errors", 1)
+	repo.MustAdd(NewTestScenario("passing", newMockExec("passing"), newChannelFactory()), "errors", 1)
+	repo.RunTestScenarios("errors", "")
+
+	errs := repo.GetErrorExecutions()
+	a.Equal(2, len(errs))
+	a.Equal("first", errs[0].Scenario())
+	a.Equal("second", errs[1].Scenario())
+}
+
+type recordingReporter struct {
+	events []string
+}
+
+func (r *recordingReporter) ScenarioStarted(run *ScenarioRun) {
+	r.events = append(r.events, "started "+run.TestGroup+"/"+run.Name)
+}
+
+func (r *recordingReporter) Report(run *ScenarioRun, execution *Execution) {
+	r.events = append(r.events, "report "+execution.TestGroup()+"/"+execution.Scenario()+" "+execution.Name())
+}
+
+func (r *recordingReporter) ScenarioFinished(run *ScenarioRun) {
+	r.events = append(r.events, "finished "+run.TestGroup+"/"+run.Name)
+}
+
+func (r *recordingReporter) ScenarioSkipped(run *ScenarioRun) {
+	r.events = append(r.events, "skipped "+run.TestGroup+"/"+run.Name+": "+run.SkipReason)
+}
+
+func Test_Repository_Reporter(t *testing.T) {
+	a := assert.New(t)
+	reporter := &recordingReporter{}
+
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("spec11", Seq("seq", newMockExec("step")), newChannelFactory()), "group1", 1)
+	repo.MustAdd(NewTestScenario("spec21", newMockExec("spec21"), newChannelFactory()), "group2", 1)
+	repo.RunTestScenarios("", "")
+
+	a.Equal([]string{
+		"started group1/spec11",
+		"report group1/spec11 seq",
+		"finished group1/spec11",
+		"started group2/spec21",
+		"report group2/spec21 spec21",
+		"finished group2/spec21",
+	}, reporter.events)
+	a.Equal("group1", repo.lastReport.Scenarios[0].Executions[0].Steps()[0].TestGroup())
+	a.Equal(1, repo.lastReport.Scenarios[0].Run.Stats.Total().Count)
+}
+
+func Test_Repository_Thresholds(t *testing.T) {
+	a := assert.New(t)
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("passing", newMockExec("passing"), newChannelFactory()).
+		WithThresholds(MustThreshold("error_rate < 1%")), "thresholds", 1)
+	repo.MustAdd(NewTestScenario("failing", newMockErrorExecution("failing"), newChannelFactory()).
+		WithThresholds(MustThreshold("error_rate < 1%"), MustThreshold("count >= 1")), "thresholds", 1)
+	repo.RunTestScenarios("thresholds", "")
+
+	failed := repo.GetFailedThresholds()
+	a.Equal(1, len(failed))
+	a.Equal("error_rate < 1%: observed 100.00%, failed", failed[0].String())
+}
+
+func Test_Repository_ThresholdAbortsRun(t *testing.T) {
+	a := assert.New(t)
+
+	executed := 0
+	scenario := NewTestScenario("aborted",
+		F("slow error", func() error {
+			executed++
+			time.Sleep(5 * time.Millisecond)
+			return errors.New("failed")
+		}),
+		func() chan Context {
+			return NewDefaultContext().Populate(1000, func(testNumber int) map[string]string { return nil })
+		}).
+		WithThresholds(MustThreshold("error_rate < 10%").AbortOnFail(time.Millisecond))
+
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(scenario, "abort", 1)
+	repo.RunTestScenarios("abort", "")
+
+	a.True(executed < 1000)
+	a.Equal(executed, len(repo.lastReport.Scenarios[0].Executions))
+	a.NotNil(repo.lastReport.Scenarios[0].Run.AbortedBy)
+	a.Equal(1, len(repo.GetFailedThresholds()))
+}
+
+func Test_Repository_Scenarios(t *testing.T) {
+	a := assert.New(t)
+
+	repo := NewRepository()
+	repo.MustAdd(NewTestScenario("spec11", newMockExec("spec11"), newChannelFactory()), "group1", 2, "foo", "bar")
+	repo.MustAdd(NewTestScenario("spec21", newMockExec("spec21"), newChannelFactory()), "group2", 1)
+
+	a.Equal([]ScenarioInfo{
+		{Name: "spec11", TestGroup: "group1", Tags: []string{"foo", "bar"}, Concurrency: 2},
+		{Name: "spec21", TestGroup: "group2", Concurrency: 1},
+	}, repo.Scenarios("", ""))
+	a.Equal(1, len(repo.Scenarios("", "", "foo")))
+	a.Empty(repo.Scenarios("group3", ""))
+}
+
+func Test_Repository_OverrideConcurrency(t *testing.T) {
+	a := assert.New(t)
+	reporter := &recordingReporter{}
+
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.OverrideConcurrency(3)
+	repo.MustAdd(NewTestScenario("spec", newMockExec("spec"), newChannelFactory()), "override", 1)
+	repo.RunTestScenarios("override", "")
+
+	a.Equal(3, repo.lastReport.Scenarios[0].Run.Concurrency)
+}
+
+func Test_Repository_AddValidates(t *testing.T) {
+	a := assert.New(t)
+
+	repo := NewRepository()
+	err := repo.Add(NewTestScenario("invalid", Seq("seq", Get("{{.Env.host")), newChannelFactory()), "group", 1)
+
+	a.Error(err)
+	a.Contains(err.Error(), `scenario "invalid": seq step 1: ->GET {{.Env.host: url: template`)
+	a.Empty(repo.Scenarios("", ""))
+	a.NoError(repo.Add(NewTestScenario("valid", Get("{{.Env.host}}"), newChannelFactory()), "group", 1))
+	a.PanicsWithError(`scenario "invalid": seq step 1: ->GET {{.Env.host: url: template: template:1: unclosed action`, func() {
+		repo.MustAdd(NewTestScenario("invalid", Seq("seq", Get("{{.Env.host")), newChannelFactory()), "group", 1)
+	})
+	a.Equal(1, len(repo.Scenarios("", "")))
+}
+
+func Test_Repository_Env(t *testing.T) {
+	a := assert.New(t)
+
+	var host string
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.SetEnv(map[string]string{"host": "staging"})
+	repo.MustAdd(NewTestScenario("env", contextExec(func(cntx Context) error {
+		host = cntx.Env()["host"]
+		return nil
+	}), func() chan Context {
+		c := make(chan Context, 1)
+		c <- NewContext(map[string]string{"host": "default"})
+		close(c)
+		return c
+	}), "env", 1)
+	repo.RunTestScenarios("env", "")
+
+	a.Equal("staging", host)
+}
+
+// contextExec is a step, which calls the function with the context.
+type contextExec func(cntx Context) error
+
+func (f contextExec) Exec(cntx Context) error {
+	return f(cntx)
+}
+
+func (f contextExec) String(cntx Context) string {
+	return "contextExec"
+}
+
+func Test_Repository_ConcurrentScenarios(t *testing.T) {
+	a := assert.New(t)
+	reporter := &recordingReporter{}
+
+	// each scenario waits for the other one, so that the run only ends, if they run concurrently
+	browsing, api := make(chan struct{}), make(chan struct{})
+	waitFor := func(started, other chan struct{}) Exec {
+		once := sync.Once{}
+		return F("step", func() error {
+			once.Do(func() { close(started) })
+			select {
+			case <-other:
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("not concurrent")
+			}
+		})
+	}
+
+	repo := NewRepository()
+	repo.SetReporters(reporter)
+	repo.SetConcurrentScenarios(true)
+	repo.MustAdd(NewTestScenario("browsing", waitFor(browsing, api), contextsFactory(3)), "load", 2)
+	repo.MustAdd(NewTestScenario("api", waitFor(api, browsing), contextsFactory(2)), "load", 1)
+	repo.MustAdd(NewTestScenario("report", newMockExec("report"), newChannelFactory()).DependsOn("browsing", "api"), "load", 1)
+	repo.RunTestScenarios("", "")
+
+	a.Empty(repo.GetErrorExecutions())
+	a.Equal(3, len(repo.lastReport.Scenarios))
+	a.Equal([]string{"browsing", "api", "report"}, []string{repo.lastReport.Scenarios[0].Run.Name, repo.lastReport.Scenarios[1].Run.Name, repo.lastReport.Scenarios[2].Run.Name})
+	a.Equal(6, repo.Summary().Stats.Total().Count)
+	a.Equal(4, repo.Summary().Concurrency)
+	a.False(repo.Summary().EndTime().IsZero())
+	// the dependent scenario started after both of its dependencies finished
+	a.Equal("started load/report", reporter.events[len(reporter.events)-3])
+	a.Equal(12, len(reporter.events))
+
+	repo.SetConcurrentScenarios(false)
+	repo.RunTestScenarios("", "report")
+	a.Nil(repo.Summary())
+}
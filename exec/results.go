@@ -0,0 +1,449 @@
+package exec
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ResultFormat is the file format for persisted executions.
+type ResultFormat string
+
+const (
+	// FormatJSONLines writes one JSON object per execution and line,
+	// with the steps nested in the object.
+	FormatJSONLines ResultFormat = "jsonl"
+	// FormatCSV writes one row per execution and step. The level
+	// column is 0 for the executions and increases for nested steps.
+	FormatCSV ResultFormat = "csv"
+)
+
+var csvHeader = []string{"level", "start", "duration_ns", "test_group", "scenario", "name", "job_title", "error", "correlation_id", "test_number", "test_data", "trace_id", "span_id"}
+
+// csvOptionalColumns are the columns, which are missing in files of older versions.
+var csvOptionalColumns = map[string]bool{"trace_id": true, "span_id": true}
+
+// csvColumns maps the column names of a CSV file to their index.
+type csvColumns map[string]int
+
+// get returns the value of the named column, or the empty string, if it is missing.
+func (columns csvColumns) get(row []string, name string) string {
+	i, exists := columns[name]
+	if !exists || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+// executionRecord is the persisted form of an execution.
+type executionRecord struct {
+	Start         time.Time          `json:"start"`
+	Duration      time.Duration      `json:"durationNs"`
+	TestGroup     string             `json:"testGroup,omitempty"`
+	Scenario      string             `json:"scenario,omitempty"`
+	Name          string             `json:"name"`
+	JobTitle      string             `json:"jobTitle"`
+	Error         string             `json:"error,omitempty"`
+	CorrelationId string             `json:"correlationId,omitempty"`
+	TestNumber    int                `json:"testNumber"`
+	TestData      map[string]string  `json:"testData,omitempty"`
+	TraceId       string             `json:"traceId,omitempty"`
+	SpanId        string             `json:"spanId,omitempty"`
+	Steps         []*executionRecord `json:"steps,omitempty"`
+}
+
+// ResultFormatOf returns the format for a file name by its extension.
+// Files ending on .csv are CSV, all others JSON lines.
+func ResultFormatOf(path string) ResultFormat {
+	if filepath.Ext(path) == ".csv" {
+		return FormatCSV
+	}
+	return FormatJSONLines
+}
+
+// ResultWriter is a reporter, which writes every execution
+// as it is reported in the configured format.
+type ResultWriter struct {
+	format    ResultFormat
+	closer    io.Closer
+	mutex     sync.Mutex
+	buffer    *bufio.Writer
+	csvWriter *csv.Writer
+	err       error
+}
+
+// NewResultWriter creates a writer to w.
+func NewResultWriter(w io.Writer, format ResultFormat) *ResultWriter {
+	writer := &ResultWriter{
+		format: format,
+		buffer: bufio.NewWriter(w),
+	}
+	if format == FormatCSV {
+		writer.csvWriter = csv.NewWriter(writer.buffer)
+		writer.err = writer.csvWriter.Write(csvHeader)
+	}
+	return writer
+}
+
+// NewResultFileWriter creates the file at path and a writer to it,
+// with the format detected by ResultFormatOf.
+func NewResultFileWriter(path string) (*ResultWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer := NewResultWriter(f, ResultFormatOf(path))
+	writer.closer = f
+	return writer, nil
+}
+
+func (writer *ResultWriter) ScenarioStarted(run *ScenarioRun) {
+}
+
+func (writer *ResultWriter) Report(run *ScenarioRun, execution *Execution) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	if writer.err != nil {
+		return
+	}
+	record := newExecutionRecord(execution)
+	if writer.format == FormatCSV {
+		writer.err = writer.writeCSV(record, 0)
+		return
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		writer.err = err
+		return
+	}
+	b = append(b, '\n')
+	_, writer.err = writer.buffer.Write(b)
+}
+
+func (writer *ResultWriter) ScenarioFinished(run *ScenarioRun) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	writer.flush()
+}
+
+// RunFinished flushes the written executions and returns the first write error.
+// A file created by NewResultFileWriter stays open for further runs,
+// until Close is called.
+func (writer *ResultWriter) RunFinished() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	writer.flush()
+	return writer.err
+}
+
+// Close flushes the writer and closes the file, if created by NewResultFileWriter.
+func (writer *ResultWriter) Close() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	writer.flush()
+	if writer.closer != nil {
+		if err := writer.closer.Close(); writer.err == nil {
+			writer.err = err
+		}
+	}
+	return writer.err
+}
+
+func (writer *ResultWriter) flush() {
+	if writer.err != nil {
+		return
+	}
+	if writer.csvWriter != nil {
+		writer.csvWriter.Flush()
+		writer.err = writer.csvWriter.Error()
+	}
+	if writer.err == nil {
+		writer.err = writer.buffer.Flush()
+	}
+}
+
+func (writer *ResultWriter) writeCSV(record *executionRecord, level int) error {
+	testData := ""
+	if len(record.TestData) > 0 {
+		b, err := json.Marshal(record.TestData)
+		if err != nil {
+			return err
+		}
+		testData = string(b)
+	}
+	err := writer.csvWriter.Write([]string{
+		strconv.Itoa(level),
+		record.Start.Format(time.RFC3339Nano),
+		strconv.FormatInt(int64(record.Duration), 10),
+		record.TestGroup,
+		record.Scenario,
+		record.Name,
+		record.JobTitle,
+		record.Error,
+		record.CorrelationId,
+		strconv.Itoa(record.TestNumber),
+		testData,
+		record.TraceId,
+		record.SpanId,
+	})
+	if err != nil {
+		return err
+	}
+	for _, step := range record.Steps {
+		if err := writer.writeCSV(step, level+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExecutionRecord(execution *Execution) *executionRecord {
+	record := &executionRecord{
+		Start:     execution.start,
+		Duration:  execution.Duration(),
+		TestGroup: execution.testGroup,
+		Scenario:  execution.scenario,
+		Name:      execution.name,
+		JobTitle:  execution.jobTitle,
+		TraceId:   execution.TraceId(),
+		SpanId:    execution.SpanId(),
+	}
+	if execution.err != nil {
+		record.Error = execution.err.Error()
+	}
+	if execution.context != nil {
+		record.CorrelationId = execution.context.CorrelationId()
+		record.TestNumber = execution.context.TestNumber()
+		record.TestData = redactedTestData(execution.context)
+	}
+	for _, step := range execution.steps {
+		record.Steps = append(record.Steps, newExecutionRecord(step))
+	}
+	return record
+}
+
+// redactedTestData returns the test data of the context with the values of secret keys replaced.
+func redactedTestData(cntx Context) map[string]string {
+	redacted := make(map[string]string, len(cntx.Test()))
+	for k, v := range cntx.Test() {
+		if isSecretIn(cntx, k) {
+			v = RedactedValue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+func (record *executionRecord) execution(parent *Execution) *Execution {
+	cntx := NewDefaultContext()
+	cntx.testNumber = record.TestNumber
+	cntx.correlationId = record.CorrelationId
+	for k, v := range record.TestData {
+		cntx.test[k] = v
+	}
+	execution := &Execution{
+		start:     record.Start,
+		end:       record.Start.Add(record.Duration),
+		name:      record.Name,
+		jobTitle:  record.JobTitle,
+		context:   cntx,
+		scenario:  record.Scenario,
+		testGroup: record.TestGroup,
+	}
+	if record.Error != "" {
+		execution.err = errors.New(record.Error)
+	}
+	hex.Decode(execution.traceId[:], []byte(record.TraceId))
+	hex.Decode(execution.spanId[:], []byte(record.SpanId))
+	if parent != nil {
+		execution.parentSpanId = parent.spanId
+	}
+	for _, step := range record.Steps {
+		execution.steps = append(execution.steps, step.execution(execution))
+	}
+	return execution
+}
+
+// LoadExecutions reads executions, which were written by a ResultWriter.
+// The contexts of the loaded executions contain the test number,
+// test data and correlation id, but no env.
+func LoadExecutions(r io.Reader, format ResultFormat) ([]*Execution, error) {
+	if format == FormatCSV {
+		return loadCSV(r)
+	}
+	executions := []*Execution{}
+	decoder := json.NewDecoder(r)
+	for {
+		record := &executionRecord{}
+		err := decoder.Decode(record)
+		if err == io.EOF {
+			return executions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		executions = append(executions, record.execution(nil))
+	}
+}
+
+// LoadExecutionsFile reads the executions from the file at path,
+// with the format detected by ResultFormatOf.
+func LoadExecutionsFile(path string) ([]*Execution, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadExecutions(f, ResultFormatOf(path))
+}
+
+// Replay reports the executions to the reporters, as if they were streamed
+// by a repository run. This way, reports can be created post hoc from
+// loaded executions. The executions are grouped by their scenario.
+// It returns the first error of the RunFinished calls.
+func Replay(executions []*Execution, reporters ...Reporter) error {
+	for _, group := range groupByScenario(executions) {
+		run := &ScenarioRun{
+			Name:      group[0].scenario,
+			TestGroup: group[0].testGroup,
+			Start:     group[0].start,
+			Stats:     NewStats(),
+		}
+		for _, execution := range group {
+			if execution.start.Before(run.Start) {
+				run.Start = execution.start
+			}
+			if execution.end.After(run.end) {
+				run.end = execution.end
+			}
+		}
+		for _, reporter := range reporters {
+			reporter.ScenarioStarted(run)
+		}
+		for _, execution := range group {
+			run.Stats.Add(execution)
+			for _, reporter := range reporters {
+				reporter.Report(run, execution)
+			}
+		}
+		for _, reporter := range reporters {
+			reporter.ScenarioFinished(run)
+		}
+	}
+
+	var err error
+	for _, reporter := range reporters {
+		if finisher, ok := reporter.(RunFinisher); ok {
+			if finishErr := finisher.RunFinished(); err == nil {
+				err = finishErr
+			}
+		}
+	}
+	return err
+}
+
+func loadCSV(r io.Reader) ([]*Execution, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return []*Execution{}, nil
+		}
+		return nil, err
+	}
+	columns := csvColumns{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range csvHeader {
+		if _, exists := columns[name]; !exists && !csvOptionalColumns[name] {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	records := []*executionRecord{}
+	// parents holds the last record of each level
+	parents := []*executionRecord{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		level, record, err := parseCSVRow(columns, row)
+		if err == nil && len(row) != len(header) {
+			err = fmt.Errorf("expected %v fields, got %v", len(header), len(row))
+		}
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+		if level < 0 || level > len(parents) {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %v: unexpected level %v", line, level)
+		}
+		parents = append(parents[:level], record)
+		if level == 0 {
+			records = append(records, record)
+		} else {
+			parent := parents[level-1]
+			parent.Steps = append(parent.Steps, record)
+		}
+	}
+
+	executions := make([]*Execution, len(records))
+	for i, record := range records {
+		executions[i] = record.execution(nil)
+	}
+	return executions, nil
+}
+
+func parseCSVRow(columns csvColumns, row []string) (int, *executionRecord, error) {
+	level, err := strconv.Atoi(columns.get(row, "level"))
+	if err != nil {
+		return 0, nil, err
+	}
+	start, err := time.Parse(time.RFC3339Nano, columns.get(row, "start"))
+	if err != nil {
+		return 0, nil, err
+	}
+	duration, err := strconv.ParseInt(columns.get(row, "duration_ns"), 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	testNumber, err := strconv.Atoi(columns.get(row, "test_number"))
+	if err != nil {
+		return 0, nil, err
+	}
+	record := &executionRecord{
+		Start:         start,
+		Duration:      time.Duration(duration),
+		TestGroup:     columns.get(row, "test_group"),
+		Scenario:      columns.get(row, "scenario"),
+		Name:          columns.get(row, "name"),
+		JobTitle:      columns.get(row, "job_title"),
+		Error:         columns.get(row, "error"),
+		CorrelationId: columns.get(row, "correlation_id"),
+		TestNumber:    testNumber,
+		TraceId:       columns.get(row, "trace_id"),
+		SpanId:        columns.get(row, "span_id"),
+	}
+	if testData := columns.get(row, "test_data"); testData != "" {
+		if err := json.Unmarshal([]byte(testData), &record.TestData); err != nil {
+			return 0, nil, err
+		}
+	}
+	return level, record, nil
+}
@@ -0,0 +1,170 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newResultsTestExecutions() []*Execution {
+	start := time.Date(2017, 1, 2, 3, 4, 5, 6, time.UTC)
+	cntx := NewDefaultContext().Derive(map[string]string{"user": "bob", "comma": "a,\"b\""}).(*ContextImpl)
+
+	first := newTestExecution("scenario", start, 3*time.Millisecond, nil,
+		newTestExecution("login", start, time.Millisecond, nil),
+		newTestExecution("inner", start, 2*time.Millisecond, nil,
+			newTestExecution("nested", start, time.Millisecond, nil)))
+	first.context = cntx
+	first.jobTitle = "scenario for bob"
+	first.setScenario("spec", "group")
+
+	second := newTestExecution("scenario", start.Add(time.Second), time.Millisecond, errors.New("failed\nbadly"),
+		newTestExecution("login", start.Add(time.Second), time.Millisecond, errors.New("failed\nbadly")))
+	second.setScenario("spec", "group")
+	return []*Execution{first, second}
+}
+
+func assertExecutionsEqual(t *testing.T, expected, actual []*Execution) {
+	a := assert.New(t)
+	a.Equal(len(expected), len(actual))
+	for i := range expected {
+		e, x := expected[i], actual[i]
+		a.True(e.start.Equal(x.start))
+		a.Equal(e.Duration(), x.Duration())
+		a.Equal(e.name, x.name)
+		a.Equal(e.jobTitle, x.jobTitle)
+		a.Equal(e.scenario, x.scenario)
+		a.Equal(e.testGroup, x.testGroup)
+		a.Equal(e.err, x.err)
+		a.Equal(e.context.CorrelationId(), x.context.CorrelationId())
+		a.Equal(e.context.TestNumber(), x.context.TestNumber())
+		a.Equal(e.context.Test(), x.context.Test())
+		a.Equal(e.TraceId(), x.TraceId())
+		a.Equal(e.SpanId(), x.SpanId())
+		a.Equal(e.ParentSpanId(), x.ParentSpanId())
+		assertExecutionsEqual(t, e.steps, x.steps)
+	}
+}
+
+func Test_Results_WriteAndLoad(t *testing.T) {
+	for _, format := range []ResultFormat{FormatJSONLines, FormatCSV} {
+		executions := newResultsTestExecutions()
+		out := bytes.NewBuffer(nil)
+		writer := NewResultWriter(out, format)
+		for _, execution := range executions {
+			writer.Report(nil, execution)
+		}
+		assert.NoError(t, writer.RunFinished())
+
+		loaded, err := LoadExecutions(bytes.NewReader(out.Bytes()), format)
+		assert.NoError(t, err)
+		assertExecutionsEqual(t, executions, loaded)
+	}
+}
+
+func Test_Results_CSVLayout(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	writer := NewResultWriter(out, FormatCSV)
+	writer.Report(nil, newResultsTestExecutions()[0])
+	assert.NoError(t, writer.RunFinished())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 5, len(lines))
+	assert.Equal(t, "level,start,duration_ns,test_group,scenario,name,job_title,error,correlation_id,test_number,test_data,trace_id,span_id", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "0,2017-01-02T03:04:05.000000006Z,3000000,group,spec,scenario,scenario for bob,,"))
+	assert.True(t, strings.HasPrefix(lines[4], "2,"))
+}
+
+func Test_Results_LoadErrors(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := LoadExecutions(strings.NewReader("{invalid"), FormatJSONLines)
+	a.Error(err)
+
+	header := strings.Join(csvHeader, ",") + "\n"
+	_, err = LoadExecutions(strings.NewReader(header+"1,2017-01-02T03:04:05Z,1,,,,,,,0,,,\n"), FormatCSV)
+	a.Error(err)
+
+	_, err = LoadExecutions(strings.NewReader(header+"0,yesterday,1,,,,,,,0,,,\n"), FormatCSV)
+	a.Error(err)
+
+	_, err = LoadExecutions(strings.NewReader(header+"0,2017-01-02T03:04:05Z,1,,,,,,,0,\n"), FormatCSV)
+	a.EqualError(err, "line 2: expected 13 fields, got 11")
+
+	_, err = LoadExecutions(strings.NewReader("level,start\n"), FormatCSV)
+	a.EqualError(err, `missing column "duration_ns"`)
+
+	executions, err := LoadExecutions(strings.NewReader(""), FormatCSV)
+	a.NoError(err)
+	a.Empty(executions)
+}
+
+func Test_Results_LoadCSVWithoutTraceColumns(t *testing.T) {
+	a := assert.New(t)
+
+	old := "level,start,duration_ns,test_group,scenario,name,job_title,error,correlation_id,test_number,test_data\n" +
+		"0,2017-01-02T03:04:05Z,3000000,group,spec,scenario,scenario for bob,failed,corr-1,7,\"{\"\"user\"\":\"\"bob\"\"}\"\n" +
+		"1,2017-01-02T03:04:05Z,1000000,group,spec,login,login,,corr-1,7,\n"
+	executions, err := LoadExecutions(strings.NewReader(old), FormatCSV)
+	a.NoError(err)
+	a.Equal(1, len(executions))
+	a.Equal("scenario", executions[0].Name())
+	a.Equal(3*time.Millisecond, executions[0].Duration())
+	a.EqualError(executions[0].Error(), "failed")
+	a.Equal("bob", executions[0].Context().Test()["user"])
+	a.Equal(7, executions[0].Context().TestNumber())
+	a.Equal("login", executions[0].Steps()[0].Name())
+
+	reordered := "name,level,start,duration_ns,test_group,scenario,job_title,error,correlation_id,test_number,test_data,span_id,trace_id\n" +
+		"spec,0,2017-01-02T03:04:05Z,1,group,spec,,,,0,,,\n"
+	executions, err = LoadExecutions(strings.NewReader(reordered), FormatCSV)
+	a.NoError(err)
+	a.Equal("spec", executions[0].Name())
+}
+
+func Test_Results_FileRoundtripWithRepository(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "results")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"results.jsonl", "results.csv"} {
+		path := filepath.Join(dir, name)
+		writer, err := NewResultFileWriter(path)
+		a.NoError(err)
+
+		repo := NewRepository()
+		repo.SetReporters(writer)
+		repo.MustAdd(NewTestScenario("spec", Seq("seq", newMockErrorExecution("step")), newChannelFactory()), "results", 1)
+		repo.RunTestScenarios("results", "")
+		a.NoError(writer.Close())
+
+		loaded, err := LoadExecutionsFile(path)
+		a.NoError(err)
+		assertExecutionsEqual(t, repo.lastReport.Scenarios[0].Executions, loaded)
+		a.Equal("step", loaded[0].Steps()[0].Name())
+
+		// the loaded executions can be aggregated again
+		stats := NewStats()
+		stats.Add(loaded[0])
+		a.Equal(1, stats.Total().Errors)
+	}
+
+	_, err = LoadExecutionsFile(filepath.Join(dir, "missing.csv"))
+	a.Error(err)
+}
+
+func Test_Results_Replay(t *testing.T) {
+	a := assert.New(t)
+
+	out := bytes.NewBuffer(nil)
+	a.NoError(Replay(newResultsTestExecutions(), NewJUnitWriterReporter(out)))
+	a.Contains(out.String(), `<testcase name="spec" classname="group" time="1.001">`)
+	a.Contains(out.String(), `<failure message="1 of 2 executions failed"`)
+}
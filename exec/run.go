@@ -1,7 +1,9 @@
 package exec
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
 )
 
 // Run does the same as RunParallel, but in one goroutine.
@@ -23,7 +25,15 @@ type parallelExecutor struct {
 	contextList   chan Context
 	spec          Exec
 	runningWorker sync.WaitGroup
+	activeWorker  int32
 	results       chan *Execution
+	abort         chan struct{}
+	abortOnce     sync.Once
+	// hooks, which run around each execution
+	beforeEach []SetupHook
+	afterEach  []TeardownHook
+	// shared is the store for the contexts without an own one, or nil
+	shared *SharedStore
 }
 
 func newParallelExecutor(spec Exec, contextList chan Context) *parallelExecutor {
@@ -32,6 +42,7 @@ func newParallelExecutor(spec Exec, contextList chan Context) *parallelExecutor
 		spec:          spec,
 		runningWorker: sync.WaitGroup{},
 		results:       make(chan *Execution, 10),
+		abort:         make(chan struct{}),
 	}
 }
 
@@ -41,21 +52,82 @@ func (ex *parallelExecutor) start(workerCount int) {
 	}
 	for i := 0; i < workerCount; i++ {
 		ex.runningWorker.Add(1)
-		go ex.startWorker()
+		atomic.AddInt32(&ex.activeWorker, 1)
+		go ex.startWorker(i)
 	}
 }
 
 func (ex *parallelExecutor) waitAndClose() {
 	ex.runningWorker.Wait()
 	close(ex.results)
+	// workers stop early, if their feed is exhausted
+	for range ex.contextList {
+	}
+}
+
+func (ex *parallelExecutor) startWorker(worker int) {
+	defer ex.runningWorker.Done()
+	defer atomic.AddInt32(&ex.activeWorker, -1)
+	for {
+		select {
+		case <-ex.abort:
+			return
+		case cntx, ok := <-ex.contextList:
+			if !ok {
+				return
+			}
+			cntx, err := feedWorker(ex.workerContext(cntx, worker))
+			if err == ErrFeedExhausted {
+				return
+			}
+			if err != nil {
+				execution := startExecutionOf(ex.spec, &cntx)
+				execution.End(err)
+				ex.results <- execution
+				continue
+			}
+			ex.results <- ex.execute(cntx)
+		}
+	}
+}
+
+// workerContext returns the context for the worker with the store of the executor.
+func (ex *parallelExecutor) workerContext(cntx Context, worker int) Context {
+	if ex.shared != nil && sharedOf(cntx) == defaultSharedStore {
+		cntx = withShared(cntx, ex.shared)
+	}
+	return withWorker(cntx, worker)
 }
 
-func (ex *parallelExecutor) startWorker() {
-	for cntx := range ex.contextList {
-		execution := StartExecution(ex.spec.String(cntx), &cntx)
-		err := ex.spec.Exec(cntx)
-		execution.End(err)
-		ex.results <- execution
+// execute executes the spec with the context between the hooks of the executor.
+func (ex *parallelExecutor) execute(cntx Context) *Execution {
+	cntx, setupErr := setUp(cntx, ex.beforeEach, nil)
+	execution := startExecutionOf(ex.spec, &cntx)
+	if setupErr != nil {
+		execution.End(fmt.Errorf("before each: %v", setupErr))
+	} else {
+		execution.End(ex.spec.Exec(cntx))
+	}
+	if err := tearDown(cntx, ex.afterEach); err != nil && execution.err == nil {
+		execution.err = redactError(cntx, fmt.Errorf("after each: %v", err))
 	}
-	ex.runningWorker.Done()
+	return execution
+}
+
+// stop lets the workers finish their current execution and stops them afterwards.
+// The remaining contexts are drained from the context channel,
+// so that a producing goroutine is not blocked forever.
+func (ex *parallelExecutor) stop() {
+	ex.abortOnce.Do(func() {
+		close(ex.abort)
+		go func() {
+			for range ex.contextList {
+			}
+		}()
+	})
+}
+
+// activeWorkers returns the number of workers, which have not finished yet.
+func (ex *parallelExecutor) activeWorkers() int {
+	return int(atomic.LoadInt32(&ex.activeWorker))
 }
@@ -0,0 +1,133 @@
+package exec
+
+import (
+	"time"
+)
+
+// RunStatus is the overall outcome of a run.
+type RunStatus string
+
+// The outcomes of a run in the order of their precedence.
+const (
+	// RunAborted is the status of a run, which was stopped by Repository.Abort.
+	RunAborted RunStatus = "aborted"
+	// RunThresholdsFailed is the status of a run, in which at least one threshold failed.
+	RunThresholdsFailed RunStatus = "thresholds failed"
+	// RunFailed is the status of a run, in which at least one execution failed or a scenario was skipped.
+	RunFailed RunStatus = "failed"
+	// RunPassed is the status of a run, in which all scenarios passed.
+	RunPassed RunStatus = "passed"
+)
+
+// RunReport is the result of a run of the repository, which is returned by RunTestScenarios and RunFiltered.
+// It is complete, when it is returned, and is not changed by later runs of the repository.
+type RunReport struct {
+	Start time.Time
+	End   time.Time
+	// Scenarios are the results of the run and skipped scenarios in the order of the run.
+	// Scenarios, which were not started because of an abort, are skipped with the AbortedSkipReason.
+	Scenarios []*ScenarioResult
+	// Summary aggregates the stats of all scenarios, if they ran concurrently, otherwise it is nil.
+	Summary *ScenarioRun
+	// Aborted is true, if the run was stopped by Repository.Abort.
+	Aborted bool
+}
+
+// ScenarioResult is the result of a single scenario of a run.
+// The run contains the stats, the verdict of the thresholds and the skip reason.
+type ScenarioResult struct {
+	Run        *ScenarioRun
+	Executions []*Execution
+}
+
+// ErrorExecutions returns the failed executions of the scenario.
+func (result *ScenarioResult) ErrorExecutions() []*Execution {
+	errorExecs := []*Execution{}
+	for _, exec := range result.Executions {
+		if exec.err != nil {
+			errorExecs = append(errorExecs, exec)
+		}
+	}
+	return errorExecs
+}
+
+// Duration returns the duration of the whole run.
+func (report *RunReport) Duration() time.Duration {
+	return report.End.Sub(report.Start)
+}
+
+// Executions returns the executions of all scenarios.
+func (report *RunReport) Executions() []*Execution {
+	executions := []*Execution{}
+	for _, result := range report.Scenarios {
+		executions = append(executions, result.Executions...)
+	}
+	return executions
+}
+
+// ErrorExecutions returns the failed executions of all scenarios.
+func (report *RunReport) ErrorExecutions() []*Execution {
+	errorExecs := []*Execution{}
+	for _, result := range report.Scenarios {
+		errorExecs = append(errorExecs, result.ErrorExecutions()...)
+	}
+	return errorExecs
+}
+
+// FailedThresholds returns the failed thresholds of all scenarios.
+func (report *RunReport) FailedThresholds() []ThresholdResult {
+	failed := []ThresholdResult{}
+	for _, result := range report.Scenarios {
+		if result.Run.Verdict != nil {
+			failed = append(failed, result.Run.Verdict.Failed()...)
+		}
+	}
+	return failed
+}
+
+// Skipped returns the results of the skipped scenarios.
+func (report *RunReport) Skipped() []*ScenarioResult {
+	skipped := []*ScenarioResult{}
+	for _, result := range report.Scenarios {
+		if result.Run.Skipped() {
+			skipped = append(skipped, result)
+		}
+	}
+	return skipped
+}
+
+// Count returns the number of executions of all scenarios.
+func (report *RunReport) Count() int {
+	count := 0
+	for _, result := range report.Scenarios {
+		count += result.Run.Stats.Total().Count
+	}
+	return count
+}
+
+// Errors returns the number of failed executions of all scenarios.
+func (report *RunReport) Errors() int {
+	errors := 0
+	for _, result := range report.Scenarios {
+		errors += result.Run.Stats.Total().Errors
+	}
+	return errors
+}
+
+// Status returns the overall outcome of the run.
+func (report *RunReport) Status() RunStatus {
+	switch {
+	case report.Aborted:
+		return RunAborted
+	case len(report.FailedThresholds()) > 0:
+		return RunThresholdsFailed
+	case report.Errors() > 0 || len(report.Skipped()) > 0:
+		return RunFailed
+	}
+	return RunPassed
+}
+
+// Passed returns true, if all scenarios passed.
+func (report *RunReport) Passed() bool {
+	return report.Status() == RunPassed
+}
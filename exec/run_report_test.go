@@ -0,0 +1,170 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RunReport(t *testing.T) {
+	a := assert.New(t)
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("ok", newMockExec("ok"), contextsFactory(3)), "report", 1)
+	repo.MustAdd(NewTestScenario("failing", newMockErrorExecution("failing"), contextsFactory(2)), "report", 1)
+	repo.MustAdd(NewTestScenario("skipped", newMockExec("skipped"), newChannelFactory()).DependsOn("failing"), "report", 1)
+
+	report, err := repo.RunTestScenarios("report", "")
+	a.NoError(err)
+
+	a.Equal(3, len(report.Scenarios))
+	a.Equal("ok", report.Scenarios[0].Run.Name)
+	a.True(report.Scenarios[0].Run.Passed())
+	a.Equal(3, len(report.Scenarios[0].Executions))
+	a.Equal(2, len(report.Scenarios[1].ErrorExecutions()))
+	a.Equal(5, len(report.Executions()))
+	a.Equal(5, report.Count())
+	a.Equal(2, report.Errors())
+	a.Equal(2, len(report.ErrorExecutions()))
+	a.Equal([]*ScenarioResult{report.Scenarios[2]}, report.Skipped())
+	a.Empty(report.FailedThresholds())
+	a.Nil(report.Summary)
+	a.False(report.Aborted)
+	a.True(report.Duration() >= report.Scenarios[0].Run.Elapsed())
+	a.Equal(RunFailed, report.Status())
+	a.False(report.Passed())
+	a.Equal(report, repo.LastReport())
+}
+
+func Test_RunReport_Status(t *testing.T) {
+	a := assert.New(t)
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("ok", newMockExec("ok"), newChannelFactory()), "passing", 1)
+	repo.MustAdd(NewTestScenario("slow", newMockExec("slow"), newChannelFactory()).
+		WithThresholds(MustThreshold("count > 5")), "thresholds", 1)
+
+	run := func(testGroupRegex string) *RunReport {
+		report, err := repo.RunTestScenarios(testGroupRegex, "")
+		a.NoError(err)
+		return report
+	}
+
+	a.Nil(repo.LastReport())
+	a.Equal(RunPassed, run("passing").Status())
+	a.True(repo.LastReport().Passed())
+	a.Equal(RunThresholdsFailed, run("thresholds").Status())
+	a.Equal(RunPassed, run("none").Status())
+
+	last := repo.LastReport()
+	report, err := repo.RunTestScenarios("(passing", "")
+	a.Error(err)
+	a.Nil(report)
+	_, err = repo.RunTestScenarios("", "", "[")
+	a.Error(err)
+	a.True(last == repo.LastReport())
+
+	reporter := &recordingReporter{}
+	repo.SetReporters(reporter)
+	repo.MustAdd(NewTestScenario("aborted", contextExec(func(cntx Context) error {
+		repo.Abort()
+		return nil
+	}), newChannelFactory()), "aborted", 1)
+	repo.MustAdd(NewTestScenario("not started", newMockExec("not started"), newChannelFactory()), "aborted", 1)
+	report = run("aborted")
+	a.True(report.Aborted)
+	a.Equal(RunAborted, report.Status())
+	a.Equal(2, len(report.Scenarios))
+	a.Equal(1, len(report.Skipped()))
+	a.Equal("not started", report.Skipped()[0].Run.Name)
+	a.Contains(reporter.events, "skipped aborted/not started: "+AbortedSkipReason)
+
+	repo.SetConcurrentScenarios(true)
+	report = run("aborted")
+	a.True(report.Aborted)
+	a.Equal(2, len(report.Scenarios))
+}
+
+func Test_RunReport_ConcurrentRuns(t *testing.T) {
+	a := assert.New(t)
+	out := bytes.NewBuffer(nil)
+	repo := NewRepository()
+	repo.SetReporters(NewJUnitWriterReporter(out))
+	repo.MustAdd(NewTestScenario("first", F("first", func() error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}), contextsFactory(20)), "first", 2)
+	repo.MustAdd(NewTestScenario("second", newMockErrorExecution("second"), contextsFactory(10)), "second", 2)
+
+	reports := make([]*RunReport, 2)
+	wg := sync.WaitGroup{}
+	for i, group := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(i int, group string) {
+			defer wg.Done()
+			var err error
+			reports[i], err = repo.RunTestScenarios(group, "")
+			a.NoError(err)
+		}(i, group)
+	}
+	wg.Wait()
+
+	a.Equal(1, len(reports[0].Scenarios))
+	a.Equal("first", reports[0].Scenarios[0].Run.Name)
+	a.Equal(20, reports[0].Count())
+	a.Equal(RunPassed, reports[0].Status())
+	a.Equal(1, len(reports[1].Scenarios))
+	a.Equal(10, reports[1].Errors())
+	a.Equal(RunFailed, reports[1].Status())
+	a.Contains(reports, repo.LastReport())
+
+	// the runs are serialized, so that each JUnit document contains the results of one run
+	decoder := xml.NewDecoder(out)
+	groups := []string{}
+	for i := 0; i < 2; i++ {
+		suites := &junitTestSuites{}
+		a.NoError(decoder.Decode(suites))
+		a.Equal(1, len(suites.Suites))
+		groups = append(groups, suites.Suites[0].Name)
+	}
+	a.ElementsMatch([]string{"first", "second"}, groups)
+}
+
+func Test_RunReport_AbortOnlyStopsTheRunningRun(t *testing.T) {
+	a := assert.New(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	repo := NewRepository()
+	repo.SetReporters()
+	repo.MustAdd(NewTestScenario("blocking", F("blocking", func() error {
+		close(started)
+		<-release
+		return nil
+	}), contextsFactory(1)), "first", 1)
+	repo.MustAdd(NewTestScenario("waiting", newMockExec("waiting"), contextsFactory(1)), "second", 1)
+
+	reports := make([]*RunReport, 2)
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		reports[0], _ = repo.RunTestScenarios("first", "")
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		reports[1], _ = repo.RunTestScenarios("second", "")
+	}()
+	repo.Abort()
+	close(release)
+	wg.Wait()
+
+	a.True(reports[0].Aborted)
+	a.False(reports[1].Aborted)
+	a.Equal(RunPassed, reports[1].Status())
+	a.True(reports[0].End.Before(reports[1].Start) || reports[0].End.Equal(reports[1].Start))
+}
@@ -0,0 +1,131 @@
+package exec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// RedactedValue replaces secret values in the output.
+const RedactedValue = "***"
+
+// secretKeys holds the keys marked by MarkSecret as map[string]bool.
+// The map is replaced on each change, so that it is read without locking on every execution.
+var secretKeys atomic.Value
+
+// secretKeysMutex serializes the changes of the secret keys.
+var secretKeysMutex sync.Mutex
+
+// MarkSecret marks keys of the env and test data as secret for all contexts of the process.
+// Their values are replaced by RedactedValue wherever godriver prints or persists data:
+// job titles, errors of executions and expectations, result files and reports.
+// Prefer Repository.MarkSecret, which limits the keys to the scenarios of a repository.
+func MarkSecret(keys ...string) {
+	secretKeysMutex.Lock()
+	defer secretKeysMutex.Unlock()
+	current := globalSecretKeys()
+	changed := make(map[string]bool, len(current)+len(keys))
+	for key := range current {
+		changed[key] = true
+	}
+	for _, key := range keys {
+		changed[key] = true
+	}
+	secretKeys.Store(changed)
+}
+
+// IsSecret returns true, if the key was marked as secret by MarkSecret.
+func IsSecret(key string) bool {
+	return globalSecretKeys()[key]
+}
+
+func globalSecretKeys() map[string]bool {
+	keys, _ := secretKeys.Load().(map[string]bool)
+	return keys
+}
+
+// hasSecretKeys returns false, if neither the context nor the process has secret keys,
+// so that the values of the context do not have to be checked.
+func hasSecretKeys(cntx Context) bool {
+	if len(globalSecretKeys()) > 0 {
+		return true
+	}
+	switch c := cntx.(type) {
+	case *ContextImpl:
+		return len(c.secrets) > 0
+	case SecretContext:
+		return true
+	}
+	return false
+}
+
+// secretValues returns the values of the secret keys of the context, or nil if there are none.
+func secretValues(cntx Context) []string {
+	if cntx == nil || !hasSecretKeys(cntx) {
+		return nil
+	}
+	var values []string
+	for _, data := range []map[string]string{cntx.Env(), cntx.Test()} {
+		for key, value := range data {
+			if isSecretIn(cntx, key) {
+				values = append(values, value)
+			}
+		}
+	}
+	for key, value := range varsOf(cntx) {
+		if isSecretIn(cntx, key) {
+			values = append(values, fmt.Sprint(value))
+		}
+	}
+	return values
+}
+
+// Redact replaces the values of the secret keys of the context
+// and the additionally supplied values in the text by RedactedValue.
+func Redact(cntx Context, text string, values ...string) string {
+	secrets := secretValues(cntx)
+	if len(secrets) == 0 && len(values) == 0 {
+		return text
+	}
+	values = append(secrets, values...)
+	if len(values) > 1 {
+		// longer values first, so that a value containing another one is replaced completely
+		sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	}
+	for _, value := range values {
+		if value != "" {
+			text = strings.Replace(text, value, RedactedValue, -1)
+		}
+	}
+	return text
+}
+
+// redactedError is an error, where the secret values were removed from the message.
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the original error, which may contain secret values.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError returns an error with the redacted message of err,
+// or err itself, if it contains no secret values.
+func redactError(cntx Context, err error, values ...string) error {
+	if err == nil {
+		return nil
+	}
+	message := Redact(cntx, err.Error(), values...)
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{message: message, err: err}
+}
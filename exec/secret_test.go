@@ -0,0 +1,126 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Secret_Redact(t *testing.T) {
+	a := assert.New(t)
+	MarkSecret("secretTestPassword", "secretTestToken")
+	cntx := NewContext(map[string]string{"secretTestPassword": "pw", "host": "example.com"})
+	cntx.Test()["secretTestToken"] = "pwtoken"
+
+	a.True(IsSecret("secretTestPassword"))
+	a.False(IsSecret("host"))
+	a.Equal("*** *** example.com ***", Redact(cntx, "pwtoken pw example.com extra", "extra"))
+
+	err := errors.New("login with pw failed")
+	redacted := redactError(cntx, err)
+	a.EqualError(redacted, "login with *** failed")
+	a.True(errors.Is(redacted, err))
+	unchanged := errors.New("nothing secret")
+	a.True(redactError(cntx, unchanged) == unchanged)
+}
+
+func Test_Secret_Execution(t *testing.T) {
+	a := assert.New(t)
+	MarkSecret("secretTestApiKey")
+	var cntx Context = NewContext(map[string]string{"secretTestApiKey": "key4711"})
+
+	execution := startExecutionOf(F("call with {{.Env.secretTestApiKey}}", nil), &cntx)
+	execution.End(errors.New("invalid key key4711"))
+
+	a.Equal("call with ***", execution.JobTitle())
+	a.EqualError(execution.Error(), "invalid key ***")
+	a.NotContains(execution.String(), "key4711")
+}
+
+func Test_Secret_ResultFile(t *testing.T) {
+	a := assert.New(t)
+	MarkSecret("secretTestPin")
+	cntx := NewDefaultContext()
+	cntx.Test()["secretTestPin"] = "1234"
+	cntx.Test()["user"] = "alice"
+
+	b := bytes.NewBuffer(nil)
+	writer := NewResultWriter(b, FormatJSONLines)
+	writer.Report(nil, &Execution{name: "x", context: cntx})
+	a.NoError(writer.RunFinished())
+
+	a.Contains(b.String(), `"secretTestPin":"***"`)
+	a.Contains(b.String(), `"user":"alice"`)
+	a.NotContains(b.String(), "1234")
+}
+
+func Test_Secret_HttpExpectation(t *testing.T) {
+	a := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		user, password, _ := req.BasicAuth()
+		resp.Write([]byte("welcome " + user + " with " + password + " and " + req.Header.Get("X-Api-Key")))
+	}))
+	defer server.Close()
+	MarkSecret("secretTestHeader")
+	cntx := NewContext(map[string]string{"secretTestHeader": "abc123"})
+
+	get := Get(server.URL).WithBasicAuth("admin", "s3cret").Contains("nothing")
+	get.Header.Set("X-Api-Key", "{{.Env.secretTestHeader}}")
+	err := get.Exec(cntx)
+
+	a.Error(err)
+	a.True(strings.Contains(err.Error(), "welcome admin with *** and ***"), err.Error())
+}
+
+func Test_Secret_Context(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewContext(map[string]string{"contextSecret": "pw"})
+	secret := cntx.WithSecrets("contextSecret")
+
+	a.True(secret.(SecretContext).IsSecret("contextSecret"))
+	a.False(cntx.IsSecret("contextSecret"))
+	a.False(IsSecret("contextSecret"))
+	a.Equal("login with ***", Redact(secret, "login with pw"))
+	a.Equal("login with pw", Redact(cntx, "login with pw"))
+	a.True(secret.Derive(nil).(SecretContext).IsSecret("contextSecret"))
+}
+
+func Test_Secret_Repository(t *testing.T) {
+	a := assert.New(t)
+	newRepository := func() *Repository {
+		repo := NewRepository()
+		repo.SetReporters()
+		repo.SetEnv(map[string]string{"apiKey": "key4711"})
+		repo.MustAdd(NewTestScenario("call", contextExec(func(cntx Context) error {
+			return errors.New("invalid key " + cntx.Env()["apiKey"])
+		}), contextsFactory(1)), "group", 1)
+		return repo
+	}
+
+	scoped := newRepository()
+	scoped.MarkSecret("apiKey")
+	report, err := scoped.RunTestScenarios("", "")
+	a.NoError(err)
+	a.EqualError(report.ErrorExecutions()[0].Error(), "invalid key ***")
+
+	report, err = newRepository().RunTestScenarios("", "")
+	a.NoError(err)
+	a.EqualError(report.ErrorExecutions()[0].Error(), "invalid key key4711")
+	a.False(IsSecret("apiKey"))
+}
+
+func Test_Secret_RedactWithoutSecretsDoesNotAllocate(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewContext(map[string]string{"host": "example.com"}).Derive(map[string]string{"user": "alice"})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		Redact(cntx, "GET example.com as alice")
+	})
+	a.Equal(float64(0), allocs)
+	a.Equal(float64(0), testing.AllocsPerRun(100, func() { IsSecret("host") }))
+}
@@ -1,5 +1,7 @@
 package exec
 
+import "fmt"
+
 type SequenceExec struct {
 	steps []Exec
 	name  string
@@ -12,13 +14,32 @@ func Seq(name string, steps ...Exec) *SequenceExec {
 	}
 }
 
-func (s *SequenceExec) String() string {
+func (s *SequenceExec) String(cntx Context) string {
+	return cntx.ExpandVarsNoError(s.name)
+}
+
+func (s *SequenceExec) Name() string {
 	return s.name
 }
 
+// Exec executes the steps one after another and stops at the first error.
+// Within a run, each step is reported as own execution and gets
+// an own correlation id, if configured per step.
 func (s *SequenceExec) Exec(cntx Context) error {
 	for _, step := range s.steps {
-		err := step.Exec(cntx)
+		if executionOf(cntx) == nil {
+			if err := step.Exec(cntx); err != nil {
+				return err
+			}
+			continue
+		}
+		stepCntx := cntx
+		if correlation := correlationOf(cntx); correlation.PerStep {
+			stepCntx = withCorrelation(stepCntx, correlation)
+		}
+		execution := startExecutionOf(step, &stepCntx)
+		err := step.Exec(stepCntx)
+		execution.End(err)
 		if err != nil {
 			return err
 		}
@@ -26,6 +47,16 @@ func (s *SequenceExec) Exec(cntx Context) error {
 	return nil
 }
 
+// Validate validates all steps.
+func (s *SequenceExec) Validate() error {
+	for i, step := range s.steps {
+		if err := Validate(step); err != nil {
+			return fmt.Errorf("%v step %v: %v", s.name, i+1, err)
+		}
+	}
+	return nil
+}
+
 // Add a Step to the SequenceExec
 func (s *SequenceExec) Add(r Exec) *SequenceExec {
 	s.steps = append(s.steps, r)
@@ -39,3 +39,47 @@ func Test_Sequence(t *testing.T) {
 	a.Error(err)
 	a.Equal("c has an error", err.Error())
 }
+
+func Test_Sequence_ReportsSteps(t *testing.T) {
+	a := assert.New(t)
+
+	seq := Seq("outer",
+		F("a", func() error { return nil }),
+		Seq("inner",
+			F("b", func() error { return nil })),
+		F("c", func() error { return errors.New("c has an error") }),
+		F("d", func() error { return nil }))
+
+	var cntx Context = NewDefaultContext()
+	execution := startExecutionOf(seq, &cntx)
+	execution.End(seq.Exec(cntx))
+
+	a.Equal("outer", execution.Name())
+	a.Error(execution.Error())
+	a.Equal(3, len(execution.Steps()))
+	a.Equal("a", execution.Steps()[0].Name())
+	a.Equal("inner", execution.Steps()[1].Name())
+	a.Equal("b", execution.Steps()[1].Steps()[0].Name())
+	a.Equal("c", execution.Steps()[2].Name())
+	a.Error(execution.Steps()[2].Error())
+}
+
+func Test_Sequence_SharesVars(t *testing.T) {
+	a := assert.New(t)
+	var token interface{}
+
+	seq := Seq("login",
+		contextExec(func(cntx Context) error {
+			cntx.(VarsContext).Vars()["token"] = []string{"a", "b"}
+			return nil
+		}),
+		contextExec(func(cntx Context) error {
+			token = cntx.(VarsContext).Vars()["token"]
+			return nil
+		}))
+
+	for result := range Run(seq, NewDefaultContext().Populate(1, func(int) map[string]string { return nil })) {
+		a.NoError(result.Error())
+	}
+	a.Equal([]string{"a", "b"}, token)
+}
@@ -0,0 +1,240 @@
+package exec
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueEmpty is returned, if a value is taken from an empty queue.
+var ErrQueueEmpty = errors.New("queue is empty")
+
+// ErrPoolTimeout is returned, if no item of a pool became available in time.
+var ErrPoolTimeout = errors.New("no pool item available")
+
+var defaultSharedStore = NewSharedStore()
+
+// DefaultSharedStore returns the store, which is used by contexts without an own one
+// outside of a repository.
+func DefaultSharedStore() *SharedStore {
+	return defaultSharedStore
+}
+
+// SharedStore holds named state, which is shared between the workers and scenarios:
+// counters, queues and pools. It is safe for concurrent use.
+// The stores are accessible by Context.Shared, also in templates, e.g.
+// {{.Shared.Next "orders"}} or {{.Shared.Pop "ids"}}.
+type SharedStore struct {
+	mutex    sync.Mutex
+	counters map[string]*SharedCounter
+	queues   map[string]*SharedQueue
+	pools    map[string]*SharedPool
+}
+
+// NewSharedStore creates an empty store.
+func NewSharedStore() *SharedStore {
+	return &SharedStore{
+		counters: make(map[string]*SharedCounter),
+		queues:   make(map[string]*SharedQueue),
+		pools:    make(map[string]*SharedPool),
+	}
+}
+
+// Counter returns the counter of the name, which is created on the first access.
+func (store *SharedStore) Counter(name string) *SharedCounter {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	counter, exists := store.counters[name]
+	if !exists {
+		counter = &SharedCounter{}
+		store.counters[name] = counter
+	}
+	return counter
+}
+
+// Queue returns the queue of the name, which is created on the first access.
+func (store *SharedStore) Queue(name string) *SharedQueue {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	queue, exists := store.queues[name]
+	if !exists {
+		queue = &SharedQueue{changed: make(chan struct{})}
+		store.queues[name] = queue
+	}
+	return queue
+}
+
+// Pool returns the pool of the name, which is created on the first access.
+func (store *SharedStore) Pool(name string) *SharedPool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	pool, exists := store.pools[name]
+	if !exists {
+		pool = &SharedPool{changed: make(chan struct{})}
+		store.pools[name] = pool
+	}
+	return pool
+}
+
+// Next increments the named counter and returns the new value.
+func (store *SharedStore) Next(counter string) int64 {
+	return store.Counter(counter).Next()
+}
+
+// Pop takes the first value of the named queue, or returns ErrQueueEmpty.
+func (store *SharedStore) Pop(queue string) (interface{}, error) {
+	value, ok := store.Queue(queue).Pop()
+	if !ok {
+		return nil, ErrQueueEmpty
+	}
+	return value, nil
+}
+
+// SharedCounter is a counter starting at 0.
+type SharedCounter struct {
+	value int64
+}
+
+// Next increments the counter and returns the new value.
+func (counter *SharedCounter) Next() int64 {
+	return counter.Add(1)
+}
+
+// Add adds delta to the counter and returns the new value.
+func (counter *SharedCounter) Add(delta int64) int64 {
+	return atomic.AddInt64(&counter.value, delta)
+}
+
+// Value returns the current value.
+func (counter *SharedCounter) Value() int64 {
+	return atomic.LoadInt64(&counter.value)
+}
+
+// SharedQueue is a first in, first out queue of values,
+// e.g. ids created by one scenario and used by another.
+type SharedQueue struct {
+	mutex   sync.Mutex
+	values  []interface{}
+	changed chan struct{}
+}
+
+// Push appends the values to the queue.
+func (queue *SharedQueue) Push(values ...interface{}) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	queue.values = append(queue.values, values...)
+	queue.changed = notify(queue.changed)
+}
+
+// Pop takes the first value of the queue. It returns false, if the queue is empty.
+func (queue *SharedQueue) Pop() (interface{}, bool) {
+	value, ok, _ := queue.take()
+	return value, ok
+}
+
+// PopWait takes the first value of the queue and waits up to the timeout
+// for a value, if the queue is empty. It returns ErrQueueEmpty after the timeout.
+func (queue *SharedQueue) PopWait(timeout time.Duration) (interface{}, error) {
+	return waitFor(timeout, ErrQueueEmpty, queue.take)
+}
+
+// take takes the first value, or returns the channel of the next change, if the queue is empty.
+func (queue *SharedQueue) take() (interface{}, bool, chan struct{}) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if len(queue.values) == 0 {
+		return nil, false, queue.changed
+	}
+	value := queue.values[0]
+	queue.values[0] = nil
+	queue.values = queue.values[1:]
+	return value, true, nil
+}
+
+// Len returns the number of values in the queue.
+func (queue *SharedQueue) Len() int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return len(queue.values)
+}
+
+// SharedPool holds items, which are used exclusively by one worker at a time,
+// e.g. pre-created accounts. An acquired item has to be released after its use.
+type SharedPool struct {
+	mutex   sync.Mutex
+	items   []interface{}
+	total   int
+	changed chan struct{}
+}
+
+// Add adds new items to the pool.
+func (pool *SharedPool) Add(items ...interface{}) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pool.items = append(pool.items, items...)
+	pool.total += len(items)
+	pool.changed = notify(pool.changed)
+}
+
+// Acquire takes an item from the pool and waits up to the timeout
+// for an item, if all are in use. It returns ErrPoolTimeout after the timeout.
+func (pool *SharedPool) Acquire(timeout time.Duration) (interface{}, error) {
+	return waitFor(timeout, ErrPoolTimeout, func() (interface{}, bool, chan struct{}) {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+		if len(pool.items) == 0 {
+			return nil, false, pool.changed
+		}
+		item := pool.items[len(pool.items)-1]
+		pool.items = pool.items[:len(pool.items)-1]
+		return item, true, nil
+	})
+}
+
+// Release returns an acquired item to the pool.
+func (pool *SharedPool) Release(item interface{}) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pool.items = append(pool.items, item)
+	pool.changed = notify(pool.changed)
+}
+
+// Available returns the number of items, which are not acquired.
+func (pool *SharedPool) Available() int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return len(pool.items)
+}
+
+// Size returns the number of items added to the pool.
+func (pool *SharedPool) Size() int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.total
+}
+
+// notify wakes up the waiting goroutines by closing the channel
+// and returns a new channel for the next change.
+func notify(changed chan struct{}) chan struct{} {
+	close(changed)
+	return make(chan struct{})
+}
+
+// waitFor calls take until it succeeds or the timeout elapsed.
+// If take does not succeed, it returns the channel, which is closed on the next change.
+func waitFor(timeout time.Duration, timeoutErr error, take func() (interface{}, bool, chan struct{})) (interface{}, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		value, ok, changed := take()
+		if ok {
+			return value, nil
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil, timeoutErr
+		}
+	}
+}
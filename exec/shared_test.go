@@ -0,0 +1,165 @@
+package exec
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Shared_Counter(t *testing.T) {
+	a := assert.New(t)
+	store := NewSharedStore()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				store.Next("orders")
+			}
+		}()
+	}
+	wg.Wait()
+
+	a.Equal(int64(1000), store.Counter("orders").Value())
+	a.Equal(int64(1001), store.Next("orders"))
+	a.Equal(int64(0), store.Counter("other").Value())
+}
+
+func Test_Shared_Queue(t *testing.T) {
+	a := assert.New(t)
+	queue := NewSharedStore().Queue("ids")
+
+	queue.Push("a", "b")
+	a.Equal(2, queue.Len())
+	v, ok := queue.Pop()
+	a.True(ok)
+	a.Equal("a", v)
+
+	v, err := queue.PopWait(time.Second)
+	a.NoError(err)
+	a.Equal("b", v)
+
+	_, ok = queue.Pop()
+	a.False(ok)
+	_, err = queue.PopWait(10 * time.Millisecond)
+	a.Equal(ErrQueueEmpty, err)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		queue.Push("c")
+	}()
+	v, err = queue.PopWait(time.Second)
+	a.NoError(err)
+	a.Equal("c", v)
+}
+
+func Test_Shared_Pool(t *testing.T) {
+	a := assert.New(t)
+	pool := NewSharedStore().Pool("accounts")
+	pool.Add("alice", "bob")
+
+	first, err := pool.Acquire(time.Second)
+	a.NoError(err)
+	second, err := pool.Acquire(time.Second)
+	a.NoError(err)
+	a.ElementsMatch([]interface{}{"alice", "bob"}, []interface{}{first, second})
+	a.Equal(0, pool.Available())
+	a.Equal(2, pool.Size())
+
+	_, err = pool.Acquire(10 * time.Millisecond)
+	a.Equal(ErrPoolTimeout, err)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Release(first)
+	}()
+	again, err := pool.Acquire(time.Second)
+	a.NoError(err)
+	a.Equal(first, again)
+}
+
+func Test_Shared_PoolExclusiveUnderRunParallel(t *testing.T) {
+	a := assert.New(t)
+	store := NewSharedStore()
+	store.Pool("accounts").Add("alice", "bob")
+	inUse := map[interface{}]bool{}
+	mutex := sync.Mutex{}
+
+	step := contextExec(func(cntx Context) error {
+		pool := cntx.(SharedContext).Shared().Pool("accounts")
+		account, err := pool.Acquire(time.Second)
+		if err != nil {
+			return err
+		}
+		defer pool.Release(account)
+		mutex.Lock()
+		a.False(inUse[account])
+		inUse[account] = true
+		mutex.Unlock()
+		time.Sleep(time.Millisecond)
+		mutex.Lock()
+		inUse[account] = false
+		mutex.Unlock()
+		return nil
+	})
+
+	contexts := NewDefaultContext().WithShared(store).Populate(50, func(int) map[string]string { return nil })
+	for execution := range RunParallel(5, step, contexts) {
+		a.NoError(execution.Error())
+	}
+	a.Equal(2, store.Pool("accounts").Available())
+}
+
+func Test_Shared_Templates(t *testing.T) {
+	a := assert.New(t)
+	store := NewSharedStore()
+	store.Queue("ids").Push(4711)
+	cntx := NewDefaultContext().WithShared(store)
+
+	a.Equal("1-2-4711", cntx.ExpandVarsNoError(`{{.Shared.Next "orders"}}-{{.Shared.Next "orders"}}-{{.Shared.Pop "ids"}}`))
+	_, err := cntx.ExpandVars(`{{.Shared.Pop "ids"}}`)
+	a.Error(err)
+	a.True(NewDefaultContext().Shared() == DefaultSharedStore())
+
+	// the counter function uses the store of the context
+	other := NewDefaultContext().WithShared(NewSharedStore())
+	a.Equal("3-4", cntx.ExpandVarsNoError(`{{counter "orders"}}-{{counter "orders"}}`))
+	a.Equal("1-2", other.ExpandVarsNoError(`{{counter "orders"}}-{{.Shared.Next "orders"}}`))
+	a.Equal(int64(4), store.Counter("orders").Value())
+}
+
+func Test_Shared_Repository(t *testing.T) {
+	a := assert.New(t)
+	newRepository := func(counted *[]string) *Repository {
+		repo := NewRepository()
+		repo.SetReporters()
+		repo.MustAdd(NewTestScenario("order", contextExec(func(cntx Context) error {
+			*counted = append(*counted, cntx.ExpandVarsNoError(`{{.Shared.Next "Test_Shared_Repository"}}`))
+			return nil
+		}), contextsFactory(2)), "group", 1)
+		return repo
+	}
+
+	first, second := []string{}, []string{}
+	firstRepo := newRepository(&first)
+	firstRepo.RunTestScenarios("", "")
+	newRepository(&second).RunTestScenarios("", "")
+	a.Equal([]string{"1", "2"}, first)
+	a.Equal([]string{"1", "2"}, second)
+	a.Equal(int64(2), firstRepo.Shared().Counter("Test_Shared_Repository").Value())
+	a.Equal(int64(0), DefaultSharedStore().Counter("Test_Shared_Repository").Value())
+	// the store is attached by the workers, so that no mapping of the contexts is needed
+	a.Nil(firstRepo.contextMapper(firstRepo.testScenarios[0], nil))
+
+	store := NewSharedStore()
+	own := []string{}
+	repo := newRepository(&own)
+	repo.SetShared(store)
+	repo.RunTestScenarios("", "")
+	a.Equal([]string{"1", "2"}, own)
+	a.Equal(int64(2), store.Counter("Test_Shared_Repository").Value())
+}
@@ -0,0 +1,162 @@
+package exec
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// metricPoint is a single measurement of an execution or step,
+// as it is pushed by the InfluxReporter and StatsDReporter.
+type metricPoint struct {
+	// measurement is "execution" for the scenario executions and "step" for the steps.
+	measurement string
+	tags        []metricTag
+	duration    time.Duration
+	failed      bool
+	time        time.Time
+}
+
+type metricTag struct {
+	key   string
+	value string
+}
+
+// sortedTags returns the labels as tags, sorted by key.
+func sortedTags(labels map[string]string) []metricTag {
+	tags := make([]metricTag, 0, len(labels))
+	for k, v := range labels {
+		tags = append(tags, metricTag{k, v})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].key < tags[j].key })
+	return tags
+}
+
+// executionPoints returns the points for the execution and all of its steps,
+// tagged with the test group, scenario, step, status and the supplied labels.
+func executionPoints(execution *Execution, labels []metricTag) []metricPoint {
+	points := []metricPoint{newMetricPoint("execution", execution, "", labels)}
+	return appendStepPoints(points, execution.steps, labels)
+}
+
+func appendStepPoints(points []metricPoint, steps []*Execution, labels []metricTag) []metricPoint {
+	for _, step := range steps {
+		points = append(points, newMetricPoint("step", step, step.name, labels))
+		points = appendStepPoints(points, step.steps, labels)
+	}
+	return points
+}
+
+func newMetricPoint(measurement string, execution *Execution, step string, labels []metricTag) metricPoint {
+	status := "ok"
+	if execution.err != nil {
+		status = "error"
+	}
+	tags := []metricTag{
+		{"test_group", execution.testGroup},
+		{"scenario", execution.scenario},
+	}
+	if step != "" {
+		tags = append(tags, metricTag{"step", step})
+	}
+	tags = append(tags, metricTag{"status", status})
+	return metricPoint{
+		measurement: measurement,
+		tags:        append(tags, labels...),
+		duration:    execution.Duration(),
+		failed:      execution.err != nil,
+		time:        execution.end,
+	}
+}
+
+// maxQueuedBatches is the number of batches, which a batchSender buffers for a slow endpoint.
+const maxQueuedBatches = 64
+
+// batchSender posts the batches of a push reporter from a background goroutine,
+// so that a slow or unreachable endpoint does not block the reporting of the executions
+// and by that the workers. If the queue is full, batches are dropped, because delaying
+// the workers would distort the measured load. The goroutine ends, when the queue is empty.
+type batchSender struct {
+	post    func(body []byte) error
+	queue   chan []byte
+	mutex   sync.Mutex
+	idle    *sync.Cond
+	pending int
+	running bool
+	dropped int
+	err     error
+}
+
+func newBatchSender(post func(body []byte) error) *batchSender {
+	sender := &batchSender{
+		post:  post,
+		queue: make(chan []byte, maxQueuedBatches),
+	}
+	sender.idle = sync.NewCond(&sender.mutex)
+	return sender
+}
+
+// send queues the batch without waiting for the endpoint.
+func (sender *batchSender) send(body []byte) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	select {
+	case sender.queue <- body:
+		sender.pending++
+	default:
+		sender.dropped++
+		return
+	}
+	if !sender.running {
+		sender.running = true
+		go sender.loop()
+	}
+}
+
+func (sender *batchSender) loop() {
+	for {
+		sender.mutex.Lock()
+		if len(sender.queue) == 0 {
+			sender.running = false
+			sender.mutex.Unlock()
+			return
+		}
+		sender.mutex.Unlock()
+
+		err := sender.post(<-sender.queue)
+
+		sender.mutex.Lock()
+		if err != nil && sender.err == nil {
+			sender.err = err
+		}
+		sender.pending--
+		sender.idle.Broadcast()
+		sender.mutex.Unlock()
+	}
+}
+
+// fail records an error, which occurred before sending.
+func (sender *batchSender) fail(err error) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	if sender.err == nil {
+		sender.err = err
+	}
+}
+
+// drain waits, until the queued batches are sent, and returns the first error since the last call.
+func (sender *batchSender) drain() error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	for sender.pending > 0 {
+		sender.idle.Wait()
+	}
+	err := sender.err
+	if err == nil && sender.dropped > 0 {
+		err = fmt.Errorf("%v batches dropped, because the endpoint was too slow", sender.dropped)
+	}
+	sender.err = nil
+	sender.dropped = 0
+	return err
+}
@@ -0,0 +1,190 @@
+package exec
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats aggregates the executions of a scenario.
+// The top level executions are aggregated as total,
+// the steps are aggregated by their name.
+// Stats is safe for concurrent use.
+type Stats struct {
+	mutex     sync.Mutex
+	total     *statsEntry
+	steps     map[string]*statsEntry
+	stepOrder []string
+}
+
+// StatsSummary is a snapshot of the aggregated values for a step or the total.
+type StatsSummary struct {
+	Name   string
+	Count  int
+	Errors int
+	// First is the start of the first execution.
+	First time.Time
+	// Last is the end of the last execution.
+	Last time.Time
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+}
+
+type statsEntry struct {
+	name      string
+	errors    int
+	first     time.Time
+	last      time.Time
+	sum       time.Duration
+	durations []time.Duration
+	sorted    bool
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		total: &statsEntry{},
+		steps: make(map[string]*statsEntry),
+	}
+}
+
+// Add aggregates the execution and all of its steps.
+func (stats *Stats) Add(execution *Execution) {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	stats.total.add(execution)
+	stats.addSteps(execution.steps)
+}
+
+func (stats *Stats) addSteps(steps []*Execution) {
+	for _, step := range steps {
+		entry, exists := stats.steps[step.name]
+		if !exists {
+			entry = &statsEntry{name: step.name}
+			stats.steps[step.name] = entry
+			stats.stepOrder = append(stats.stepOrder, step.name)
+		}
+		entry.add(step)
+		stats.addSteps(step.steps)
+	}
+}
+
+// Total returns the summary over all top level executions.
+func (stats *Stats) Total() StatsSummary {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	return stats.total.summary()
+}
+
+// Step returns the summary of the named step and false, if there was no such step.
+func (stats *Stats) Step(name string) (StatsSummary, bool) {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	entry, exists := stats.steps[name]
+	if !exists {
+		return StatsSummary{Name: name}, false
+	}
+	return entry.summary(), true
+}
+
+// Steps returns the summaries of all steps in the order of their first occurrence.
+func (stats *Stats) Steps() []StatsSummary {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	summaries := make([]StatsSummary, 0, len(stats.stepOrder))
+	for _, name := range stats.stepOrder {
+		summaries = append(summaries, stats.steps[name].summary())
+	}
+	return summaries
+}
+
+// Percentile returns the p-th percentile (0 < p <= 100) of the durations of the named step.
+// The empty name stands for the total.
+func (stats *Stats) Percentile(name string, p float64) time.Duration {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+	entry := stats.total
+	if name != "" {
+		var exists bool
+		if entry, exists = stats.steps[name]; !exists {
+			return 0
+		}
+	}
+	return entry.percentile(p)
+}
+
+func (entry *statsEntry) add(execution *Execution) {
+	if len(entry.durations) == 0 || execution.start.Before(entry.first) {
+		entry.first = execution.start
+	}
+	if execution.end.After(entry.last) {
+		entry.last = execution.end
+	}
+	if execution.err != nil {
+		entry.errors++
+	}
+	entry.sum += execution.Duration()
+	entry.durations = append(entry.durations, execution.Duration())
+	entry.sorted = false
+}
+
+func (entry *statsEntry) percentile(p float64) time.Duration {
+	if len(entry.durations) == 0 {
+		return 0
+	}
+	if !entry.sorted {
+		sort.Slice(entry.durations, func(i, j int) bool { return entry.durations[i] < entry.durations[j] })
+		entry.sorted = true
+	}
+	rank := int(math.Ceil(p/100*float64(len(entry.durations)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(entry.durations) {
+		rank = len(entry.durations) - 1
+	}
+	return entry.durations[rank]
+}
+
+func (entry *statsEntry) summary() StatsSummary {
+	summary := StatsSummary{
+		Name:   entry.name,
+		Count:  len(entry.durations),
+		Errors: entry.errors,
+		First:  entry.first,
+		Last:   entry.last,
+	}
+	if summary.Count == 0 {
+		return summary
+	}
+	summary.Min = entry.percentile(0)
+	summary.Max = entry.percentile(100)
+	summary.Mean = entry.sum / time.Duration(summary.Count)
+	summary.P50 = entry.percentile(50)
+	summary.P90 = entry.percentile(90)
+	summary.P95 = entry.percentile(95)
+	summary.P99 = entry.percentile(99)
+	return summary
+}
+
+// ErrorRate returns the fraction of failed executions between 0 and 1.
+func (summary StatsSummary) ErrorRate() float64 {
+	if summary.Count == 0 {
+		return 0
+	}
+	return float64(summary.Errors) / float64(summary.Count)
+}
+
+// Throughput returns the executions per second between the first start and the last end.
+func (summary StatsSummary) Throughput() float64 {
+	elapsed := summary.Last.Sub(summary.First).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(summary.Count) / elapsed
+}
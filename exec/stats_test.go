@@ -0,0 +1,73 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestExecution(name string, start time.Time, duration time.Duration, err error, steps ...*Execution) *Execution {
+	return &Execution{
+		start:    start,
+		end:      start.Add(duration),
+		name:     name,
+		jobTitle: name,
+		err:      err,
+		context:  NewDefaultContext(),
+		steps:    steps,
+	}
+}
+
+func Test_Stats(t *testing.T) {
+	a := assert.New(t)
+	start := time.Now()
+
+	stats := NewStats()
+	for i := 1; i <= 100; i++ {
+		var err error
+		if i%10 == 0 {
+			err = errors.New("failed")
+		}
+		stats.Add(newTestExecution("scenario", start, time.Duration(i)*time.Millisecond, err,
+			newTestExecution("login", start, time.Duration(i)*time.Millisecond/2, nil),
+			newTestExecution("logout", start, time.Millisecond, err)))
+	}
+
+	total := stats.Total()
+	a.Equal(100, total.Count)
+	a.Equal(10, total.Errors)
+	a.Equal(0.1, total.ErrorRate())
+	a.Equal(time.Millisecond, total.Min)
+	a.Equal(100*time.Millisecond, total.Max)
+	a.Equal(50*time.Millisecond, total.P50)
+	a.Equal(95*time.Millisecond, total.P95)
+	a.Equal(99*time.Millisecond, total.P99)
+	a.InDelta(1000, total.Throughput(), 0.001)
+
+	steps := stats.Steps()
+	a.Equal(2, len(steps))
+	a.Equal("login", steps[0].Name)
+	a.Equal("logout", steps[1].Name)
+
+	login, exists := stats.Step("login")
+	a.True(exists)
+	a.Equal(100, login.Count)
+	a.Equal(0, login.Errors)
+	a.Equal(25*time.Millisecond, login.P50)
+	a.Equal(time.Duration(49500)*time.Microsecond, stats.Percentile("login", 99))
+	a.Equal(90*time.Millisecond, stats.Percentile("", 90))
+
+	_, exists = stats.Step("unknown")
+	a.False(exists)
+	a.Equal(time.Duration(0), stats.Percentile("unknown", 50))
+}
+
+func Test_Stats_Empty(t *testing.T) {
+	a := assert.New(t)
+
+	total := NewStats().Total()
+	a.Equal(0, total.Count)
+	a.Equal(0.0, total.ErrorRate())
+	a.Equal(0.0, total.Throughput())
+}
@@ -0,0 +1,109 @@
+package exec
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// statsdMaxPacketSize is the maximum size of a datagram, which fits into an ethernet frame.
+const statsdMaxPacketSize = 1432
+
+// StatsDReporter sends the executions as StatsD metrics over UDP.
+// The tags test_group, scenario, step, status and the configured labels
+// are appended in the DogStatsD format (|#key:value,...).
+// Multiple metrics are batched into one datagram.
+//
+// For each execution <prefix>.execution.duration (timer) and
+// <prefix>.execution.count (counter) are sent, for each step <prefix>.step.duration and <prefix>.step.count.
+type StatsDReporter struct {
+	prefix string
+	labels []metricTag
+	conn   net.Conn
+	mutex  sync.Mutex
+	packet *bytes.Buffer
+	err    error
+}
+
+// NewStatsDReporter creates a reporter sending to the StatsD listener at addr.
+func NewStatsDReporter(addr, prefix string, labels map[string]string) (*StatsDReporter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsDReporter{
+		prefix: prefix,
+		labels: sortedTags(labels),
+		conn:   conn,
+		packet: bytes.NewBuffer(nil),
+	}, nil
+}
+
+func (reporter *StatsDReporter) ScenarioStarted(run *ScenarioRun) {
+}
+
+func (reporter *StatsDReporter) Report(run *ScenarioRun, execution *Execution) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	for _, point := range executionPoints(execution, reporter.labels) {
+		tags := statsdTags(point.tags)
+		name := reporter.prefix + "." + point.measurement
+		reporter.write(name + ".duration:" + strconv.FormatFloat(point.duration.Seconds()*1000, 'f', -1, 64) + "|ms" + tags)
+		reporter.write(name + ".count:1|c" + tags)
+	}
+}
+
+func (reporter *StatsDReporter) ScenarioFinished(run *ScenarioRun) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.flush()
+}
+
+// RunFinished sends the remaining metrics and returns the first error since the last call.
+func (reporter *StatsDReporter) RunFinished() error {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.flush()
+	err := reporter.err
+	reporter.err = nil
+	return err
+}
+
+// Close closes the connection.
+func (reporter *StatsDReporter) Close() error {
+	return reporter.conn.Close()
+}
+
+// write adds the metric to the packet and sends the packet before, if it would become too large.
+func (reporter *StatsDReporter) write(metric string) {
+	if reporter.packet.Len() > 0 && reporter.packet.Len()+1+len(metric) > statsdMaxPacketSize {
+		reporter.flush()
+	}
+	if reporter.packet.Len() > 0 {
+		reporter.packet.WriteByte('\n')
+	}
+	reporter.packet.WriteString(metric)
+}
+
+func (reporter *StatsDReporter) flush() {
+	if reporter.packet.Len() == 0 {
+		return
+	}
+	_, err := reporter.conn.Write(reporter.packet.Bytes())
+	reporter.packet.Reset()
+	if err != nil && reporter.err == nil {
+		reporter.err = err
+	}
+}
+
+var statsdTagEscaper = strings.NewReplacer(",", "_", "|", "_", "\n", "_", "#", "_")
+
+func statsdTags(tags []metricTag) string {
+	formatted := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		formatted = append(formatted, statsdTagEscaper.Replace(tag.key)+":"+statsdTagEscaper.Replace(tag.value))
+	}
+	return "|#" + strings.Join(formatted, ",")
+}
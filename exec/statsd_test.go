@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_StatsDReporter(t *testing.T) {
+	a := assert.New(t)
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	a.NoError(err)
+	defer listener.Close()
+
+	reporter, err := NewStatsDReporter(listener.LocalAddr().String(), "godriver", map[string]string{"run": "nightly"})
+	a.NoError(err)
+	defer reporter.Close()
+
+	for i := 0; i < 10; i++ {
+		reporter.Report(nil, newSinkTestExecution(nil))
+	}
+	a.NoError(reporter.RunFinished())
+
+	packets := []string{}
+	buffer := make([]byte, 2*statsdMaxPacketSize)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	received := 0
+	for received < 40 {
+		n, _, err := listener.ReadFrom(buffer)
+		if !a.NoError(err) {
+			break
+		}
+		a.True(n <= statsdMaxPacketSize)
+		packets = append(packets, string(buffer[:n]))
+		received += strings.Count(string(buffer[:n]), "\n") + 1
+	}
+
+	a.True(len(packets) > 1)
+	metrics := strings.Split(packets[0], "\n")
+	a.Equal("godriver.execution.duration:12.5|ms|#test_group:group_1,scenario:spec,status:ok,run:nightly", metrics[0])
+	a.Equal("godriver.execution.count:1|c|#test_group:group_1,scenario:spec,status:ok,run:nightly", metrics[1])
+	a.Equal("godriver.step.duration:2|ms|#test_group:group_1,scenario:spec,step:log in,status:ok,run:nightly", metrics[2])
+}
@@ -0,0 +1,202 @@
+package exec
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// TagExpression is a boolean expression over the tags of a scenario, e.g.
+//
+//	smoke && !slow
+//	(api || web) && prod-safe
+//
+// A term matches, if it equals one of the tags, where * matches any characters, e.g. api-*.
+// The operators are ! (not), && (and) and || (or) in the order of their precedence,
+// parentheses group sub expressions. The empty expression matches all tags.
+type TagExpression struct {
+	source string
+	root   tagNode
+}
+
+type tagNode interface {
+	matches(tags []string) bool
+}
+
+type tagTerm string
+
+type tagNot struct {
+	node tagNode
+}
+
+type tagAnd struct {
+	left, right tagNode
+}
+
+type tagOr struct {
+	left, right tagNode
+}
+
+// ParseTagExpression parses a tag expression.
+func ParseTagExpression(expression string) (*TagExpression, error) {
+	parser := &tagParser{tokens: tokenizeTagExpression(expression)}
+	if len(parser.tokens) == 0 {
+		return &TagExpression{source: expression}, nil
+	}
+	root, err := parser.or()
+	if err == nil && parser.position < len(parser.tokens) {
+		err = parser.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", expression, err)
+	}
+	return &TagExpression{source: expression, root: root}, nil
+}
+
+// MustTagExpression parses the expression and panics, if it is invalid.
+func MustTagExpression(expression string) *TagExpression {
+	tagExpression, err := ParseTagExpression(expression)
+	if err != nil {
+		panic(err)
+	}
+	return tagExpression
+}
+
+// Matches returns true, if the tags fulfill the expression.
+func (expression *TagExpression) Matches(tags ...string) bool {
+	if expression.root == nil {
+		return true
+	}
+	return expression.root.matches(tags)
+}
+
+func (expression *TagExpression) String() string {
+	return expression.source
+}
+
+func (term tagTerm) matches(tags []string) bool {
+	for _, tag := range tags {
+		if matched, _ := path.Match(string(term), tag); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (not tagNot) matches(tags []string) bool {
+	return !not.node.matches(tags)
+}
+
+func (and tagAnd) matches(tags []string) bool {
+	return and.left.matches(tags) && and.right.matches(tags)
+}
+
+func (or tagOr) matches(tags []string) bool {
+	return or.left.matches(tags) || or.right.matches(tags)
+}
+
+type tagToken struct {
+	text     string
+	position int
+}
+
+// tokenizeTagExpression splits the expression into operators, parentheses and terms.
+// Single & and | characters are returned as own tokens, so that the parser reports them.
+func tokenizeTagExpression(expression string) []tagToken {
+	tokens := []tagToken{}
+	for i := 0; i < len(expression); {
+		switch c := expression[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case strings.HasPrefix(expression[i:], "&&") || strings.HasPrefix(expression[i:], "||"):
+			tokens = append(tokens, tagToken{expression[i : i+2], i})
+			i += 2
+		case strings.IndexByte("!()&|", c) >= 0:
+			tokens = append(tokens, tagToken{expression[i : i+1], i})
+			i++
+		default:
+			end := i
+			for end < len(expression) && strings.IndexByte(" \t\n!()&|", expression[end]) < 0 {
+				end++
+			}
+			tokens = append(tokens, tagToken{expression[i:end], i})
+			i = end
+		}
+	}
+	return tokens
+}
+
+// tagParser is a recursive descent parser for:
+//
+//	or    = and { "||" and }
+//	and   = unary { "&&" unary }
+//	unary = "!" unary | "(" or ")" | term
+type tagParser struct {
+	tokens   []tagToken
+	position int
+}
+
+func (parser *tagParser) or() (tagNode, error) {
+	left, err := parser.and()
+	for err == nil && parser.next("||") {
+		var right tagNode
+		right, err = parser.and()
+		left = tagOr{left, right}
+	}
+	return left, err
+}
+
+func (parser *tagParser) and() (tagNode, error) {
+	left, err := parser.unary()
+	for err == nil && parser.next("&&") {
+		var right tagNode
+		right, err = parser.unary()
+		left = tagAnd{left, right}
+	}
+	return left, err
+}
+
+func (parser *tagParser) unary() (tagNode, error) {
+	switch {
+	case parser.position == len(parser.tokens):
+		return nil, fmt.Errorf("unexpected end")
+	case parser.next("!"):
+		node, err := parser.unary()
+		return tagNot{node}, err
+	case parser.next("("):
+		node, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+		if !parser.next(")") {
+			if parser.position == len(parser.tokens) {
+				return nil, fmt.Errorf("missing )")
+			}
+			return nil, parser.unexpected()
+		}
+		return node, nil
+	}
+	token := parser.tokens[parser.position]
+	if strings.IndexByte("!()&|", token.text[0]) >= 0 {
+		return nil, parser.unexpected()
+	}
+	if _, err := path.Match(token.text, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q", token.text)
+	}
+	parser.position++
+	return tagTerm(token.text), nil
+}
+
+// next consumes the next token, if it is the supplied one.
+func (parser *tagParser) next(text string) bool {
+	if parser.position < len(parser.tokens) && parser.tokens[parser.position].text == text {
+		parser.position++
+		return true
+	}
+	return false
+}
+
+func (parser *tagParser) unexpected() error {
+	token := parser.tokens[parser.position]
+	return fmt.Errorf("unexpected %q at position %v", token.text, token.position+1)
+}
@@ -0,0 +1,57 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TagExpression_Matches(t *testing.T) {
+	tests := []struct {
+		expression string
+		tags       []string
+		matches    bool
+	}{
+		{"", nil, true},
+		{"smoke", []string{"smoke", "slow"}, true},
+		{"smoke", []string{"regression"}, false},
+		{"smoke && !slow", []string{"smoke"}, true},
+		{"smoke && !slow", []string{"smoke", "slow"}, false},
+		{"(api || web) && prod-safe", []string{"web", "prod-safe"}, true},
+		{"(api || web) && prod-safe", []string{"web"}, false},
+		{"api || web && prod-safe", []string{"api"}, true},
+		{"!(api || web)", []string{"batch"}, true},
+		{"!!api", []string{"api"}, true},
+		{"api-*", []string{"api-v2"}, true},
+		{"api-*", []string{"web-v2"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			expression := MustTagExpression(test.expression)
+			assert.Equal(t, test.matches, expression.Matches(test.tags...))
+			assert.Equal(t, test.expression, expression.String())
+		})
+	}
+}
+
+func Test_TagExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"smoke &&", `invalid expression "smoke &&": unexpected end`},
+		{"smoke slow", `invalid expression "smoke slow": unexpected "slow" at position 7`},
+		{"smoke & slow", `invalid expression "smoke & slow": unexpected "&" at position 7`},
+		{"(api || web", `invalid expression "(api || web": missing )`},
+		{"api)", `invalid expression "api)": unexpected ")" at position 4`},
+		{"|| api", `invalid expression "|| api": unexpected "||" at position 1`},
+		{"api-[", `invalid expression "api-[": invalid pattern "api-["`},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := ParseTagExpression(test.expression)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+	assert.Panics(t, func() { MustTagExpression("(") })
+}
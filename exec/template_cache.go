@@ -0,0 +1,79 @@
+package exec
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+)
+
+// maxCachedTemplates limits the number of parsed templates, which are kept in memory.
+// Templates beyond the limit are parsed on each use.
+const maxCachedTemplates = 10000
+
+// templates holds the current *templateSet. It is replaced on the registration
+// of template functions, which drops the templates parsed with the old functions.
+var templates atomic.Value
+
+func init() {
+	set := newTemplateSet(defaultTemplateFuncs())
+	set.sharedCounter = true
+	templates.Store(set)
+}
+
+// templateSet caches the parsed templates for a set of functions.
+type templateSet struct {
+	funcs  template.FuncMap
+	cache  sync.Map
+	cached int32
+	// sharedCounter is true, if the counter function is the builtin one,
+	// which is bound to the store of the context.
+	sharedCounter bool
+}
+
+func newTemplateSet(funcs template.FuncMap) *templateSet {
+	return &templateSet{funcs: funcs}
+}
+
+func currentTemplates() *templateSet {
+	return templates.Load().(*templateSet)
+}
+
+// parse returns the parsed template, from the cache if possible.
+// Parse errors are not cached.
+func (set *templateSet) parse(tpl string) (*template.Template, error) {
+	if t, exists := set.cache.Load(tpl); exists {
+		return t.(*template.Template), nil
+	}
+	t, err := template.New("template").Funcs(set.funcs).Parse(tpl)
+	if err != nil {
+		return nil, err
+	}
+	if atomic.LoadInt32(&set.cached) < maxCachedTemplates {
+		if _, loaded := set.cache.LoadOrStore(tpl, t); !loaded {
+			atomic.AddInt32(&set.cached, 1)
+		}
+	}
+	return t, nil
+}
+
+// parseWithStore returns the parsed template like parse. If the template uses the builtin
+// counter function, it is bound to the supplied store on a copy of the cached template.
+func (set *templateSet) parseWithStore(tpl string, store *SharedStore) (*template.Template, error) {
+	t, err := set.parse(tpl)
+	if err != nil || !set.sharedCounter || !strings.Contains(tpl, "counter") {
+		return t, err
+	}
+	bound, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return bound.Funcs(template.FuncMap{"counter": store.Next}), nil
+}
+
+// ValidateTemplate parses the template, as ExpandVars does, and returns the parse error, if any.
+// The parsed template is cached for its later execution.
+func ValidateTemplate(tpl string) error {
+	_, err := currentTemplates().parse(tpl)
+	return err
+}
@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TemplateCache(t *testing.T) {
+	a := assert.New(t)
+	set := newTemplateSet(defaultTemplateFuncs())
+
+	first, err := set.parse("{{.Test.id}}")
+	a.NoError(err)
+	second, err := set.parse("{{.Test.id}}")
+	a.NoError(err)
+	a.True(first == second)
+
+	_, err = set.parse("{{.Test.id")
+	a.Error(err)
+	_, exists := set.cache.Load("{{.Test.id")
+	a.False(exists)
+}
+
+func Test_TemplateCache_ReplacedOnRegistration(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewDefaultContext()
+
+	a.Error(ValidateTemplate(`{{cacheTestFunc}}`))
+	RegisterTemplateFunc("cacheTestFunc", func() string { return "registered" })
+	a.NoError(ValidateTemplate(`{{cacheTestFunc}}`))
+	a.Equal("registered", cntx.ExpandVarsNoError(`{{cacheTestFunc}}`))
+}
+
+func Benchmark_ExpandVars(b *testing.B) {
+	cntx := NewContext(map[string]string{"host": "example.com"})
+	cntx.Test()["id"] = "4711"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cntx.ExpandVars("http://{{.Env.host}}/items/{{.Test.id}}")
+	}
+}
+
+func Benchmark_ExpandVars_Plain(b *testing.B) {
+	cntx := NewDefaultContext()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cntx.ExpandVars("http://example.com/items")
+	}
+}
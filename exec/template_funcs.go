@@ -0,0 +1,138 @@
+package exec
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// templateFuncsMutex serializes the registration of functions.
+var templateFuncsMutex sync.Mutex
+
+// defaultTemplateFuncs returns the functions, which are available in all templates.
+// The value of pipelines is the last argument, e.g. {{.Test.user | upper}} or {{now | timeAdd "-1h" | timeFormat "2006-01-02"}}.
+func defaultTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// ids
+		"uuid": newUUID,
+		"ulid": func() string { return newULID(time.Now()) },
+
+		// random values
+		"randInt":    func(min, max int) int { return min + rand.Intn(max-min+1) },
+		"randString": randStringBytes,
+		"randChoice": func(choices ...string) string { return choices[rand.Intn(len(choices))] },
+
+		// fake generates fake data of a FakeKind with an optional locale, e.g. {{fake "email" "de"}}.
+		"fake": fakeTemplateFunc,
+
+		// time
+		"now":        time.Now,
+		"timeFormat": func(layout string, t time.Time) string { return t.Format(layout) },
+		"timeAdd": func(duration string, t time.Time) (time.Time, error) {
+			d, err := time.ParseDuration(duration)
+			return t.Add(d), err
+		},
+		"unix":      func(t time.Time) int64 { return t.Unix() },
+		"unixMilli": func(t time.Time) int64 { return t.UnixNano() / int64(time.Millisecond) },
+
+		// encoding
+		"base64":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"base64Decode": func(s string) (string, error) { b, err := base64.StdEncoding.DecodeString(s); return string(b), err },
+		"urlEncode":    url.QueryEscape,
+		"urlDecode":    url.QueryUnescape,
+		"pathEscape":   url.PathEscape,
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+
+		// hashes in hex encoding
+		"md5":    func(s string) string { sum := md5.Sum([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"sha1":   func(s string) string { sum := sha1.Sum([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"sha256": func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"hmacSha256": func(key, s string) string {
+			mac := hmac.New(sha256.New, []byte(key))
+			mac.Write([]byte(s))
+			return hex.EncodeToString(mac.Sum(nil))
+		},
+
+		// counter returns the next value of the named counter of the context's Shared store, starting with 1.
+		// Outside of a ContextImpl, it uses the DefaultSharedStore.
+		"counter": func(name string) int64 {
+			return defaultSharedStore.Next(name)
+		},
+
+		// env returns the variable of the operating system environment.
+		// The env of the context is available as .Env.
+		"env": os.Getenv,
+
+		// strings
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"trim":      strings.TrimSpace,
+		"replace":   func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+		"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":     func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":      func(sep string, elems []string) string { return strings.Join(elems, sep) },
+		"repeat":    func(count int, s string) string { return strings.Repeat(s, count) },
+		"trunc": func(length int, s string) string {
+			if len(s) > length {
+				return s[:length]
+			}
+			return s
+		},
+		"default": func(def string, s interface{}) interface{} {
+			if s == nil || s == "" {
+				return def
+			}
+			return s
+		},
+	}
+}
+
+// TemplateFuncs returns a copy of the functions available in the templates of ExpandVars.
+func TemplateFuncs() template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, f := range currentTemplates().funcs {
+		funcs[name] = f
+	}
+	return funcs
+}
+
+// RegisterTemplateFunc makes a function available in the templates of ExpandVars,
+// e.g. in the Url, Header and Body of a HttpExec. An existing function of the same
+// name is replaced. The function has to follow the rules of template.FuncMap,
+// otherwise RegisterTemplateFunc panics.
+func RegisterTemplateFunc(name string, f interface{}) {
+	RegisterTemplateFuncs(template.FuncMap{name: f})
+}
+
+// RegisterTemplateFuncs registers all functions of the map, like RegisterTemplateFunc.
+func RegisterTemplateFuncs(funcs template.FuncMap) {
+	// validates the functions
+	template.New("").Funcs(funcs)
+
+	templateFuncsMutex.Lock()
+	defer templateFuncsMutex.Unlock()
+	merged := TemplateFuncs()
+	for name, f := range funcs {
+		merged[name] = f
+	}
+	set := newTemplateSet(merged)
+	_, replaced := funcs["counter"]
+	set.sharedCounter = currentTemplates().sharedCounter && !replaced
+	templates.Store(set)
+}
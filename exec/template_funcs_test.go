@@ -0,0 +1,85 @@
+package exec
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TemplateFuncs(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewContext(map[string]string{"host": "example.com"})
+	cntx.Test()["user"] = " Alice "
+	os.Setenv("GODRIVER_TEMPLATE_TEST", "from os")
+	defer os.Unsetenv("GODRIVER_TEMPLATE_TEST")
+
+	for _, test := range []struct {
+		template string
+		expected string
+	}{
+		{`{{.Test.user | trim | upper}}`, "ALICE"},
+		{`{{.Test.user | trim | lower | repeat 2}}`, "alicealice"},
+		{`{{"a b&c" | urlEncode}}`, "a+b%26c"},
+		{`{{"a b" | pathEscape}}`, "a%20b"},
+		{`{{"user:pw" | base64}}`, "dXNlcjpwdw=="},
+		{`{{"dXNlcjpwdw==" | base64Decode}}`, "user:pw"},
+		{`{{json .Env}}`, `{"host":"example.com"}`},
+		{`{{"abc" | md5}}`, "900150983cd24fb0d6963f7d28e17f72"},
+		{`{{"abc" | sha1}}`, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{`{{"abc" | sha256}}`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`{{"The quick brown fox jumps over the lazy dog" | hmacSha256 "key"}}`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{`{{env "GODRIVER_TEMPLATE_TEST"}}`, "from os"},
+		{`{{.Test.missing | default "none"}}`, "none"},
+		{`{{"a,b" | split "," | join "-"}}`, "a-b"},
+		{`{{"foobar" | trunc 3}}`, "foo"},
+		{`{{"foobar" | replace "o" "0"}}`, "f00bar"},
+		{`{{if hasPrefix "foo" "foobar"}}yes{{end}}`, "yes"},
+		{`{{randChoice "x" "x"}}`, "x"},
+		{`{{randInt 5 5}}`, "5"},
+	} {
+		result, err := cntx.ExpandVars(test.template)
+		a.NoError(err, test.template)
+		a.Equal(test.expected, result, test.template)
+	}
+}
+
+func Test_TemplateFuncs_Generated(t *testing.T) {
+	a := assert.New(t)
+	cntx := NewDefaultContext()
+
+	a.Regexp(regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), cntx.ExpandVarsNoError(`{{uuid}}`))
+	a.Equal(26, len(cntx.ExpandVarsNoError(`{{ulid}}`)))
+	a.Equal(12, len(cntx.ExpandVarsNoError(`{{randString 12}}`)))
+
+	year := cntx.ExpandVarsNoError(`{{now | timeAdd "-24h" | timeFormat "2006"}}`)
+	a.Equal(time.Now().Add(-24*time.Hour).Format("2006"), year)
+	unix, err := strconv.ParseInt(cntx.ExpandVarsNoError(`{{now | unix}}`), 10, 64)
+	a.NoError(err)
+	a.InDelta(time.Now().Unix(), unix, 2)
+
+	first, _ := strconv.Atoi(cntx.ExpandVarsNoError(`{{counter "Test_TemplateFuncs_Generated"}}`))
+	second, _ := strconv.Atoi(cntx.ExpandVarsNoError(`{{counter "Test_TemplateFuncs_Generated"}}`))
+	a.Equal(first+1, second)
+
+	_, err = cntx.ExpandVars(`{{now | timeAdd "foo"}}`)
+	a.Error(err)
+}
+
+func Test_TemplateFuncs_Register(t *testing.T) {
+	a := assert.New(t)
+
+	RegisterTemplateFunc("greet", func(name string) string { return "hello " + name })
+	a.Equal("hello bob", NewDefaultContext().ExpandVarsNoError(`{{greet "bob"}}`))
+	a.Contains(TemplateFuncs(), "greet")
+	a.Contains(TemplateFuncs(), "uuid")
+
+	a.Panics(func() {
+		RegisterTemplateFunc("invalid", "no function")
+	})
+	a.NotContains(TemplateFuncs(), "invalid")
+	a.Equal("OK", NewDefaultContext().ExpandVarsNoError(`{{upper "ok"}}`))
+}
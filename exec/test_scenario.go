@@ -4,12 +4,66 @@ type TestScenario struct {
 	Name                  string
 	Exec                  Exec
 	ContextChannelFactory func() chan Context
+	// ExpectedExecutions is the optional number of contexts,
+	// the channel will supply. It is used to estimate the remaining time.
+	ExpectedExecutions int
+	// Thresholds are evaluated on the aggregated results of the scenario.
+	Thresholds []*Threshold
+	// Correlation overrides the correlation configuration of the repository, if set.
+	Correlation *CorrelationConfig
+	// Hooks are the setup and teardown hooks of the scenario.
+	Hooks Hooks
+	// Dependencies are the names of the scenarios, which have to pass before this one runs.
+	Dependencies []string
 }
 
 func NewTestScenario(name string, exec Exec, contextChannelFactory func() chan Context) *TestScenario {
 	return &TestScenario{
-		Name: name,
-		Exec: exec,
+		Name:                  name,
+		Exec:                  exec,
 		ContextChannelFactory: contextChannelFactory,
 	}
 }
+
+// WithThresholds adds pass/fail criteria to the scenario.
+func (scenario *TestScenario) WithThresholds(thresholds ...*Threshold) *TestScenario {
+	scenario.Thresholds = append(scenario.Thresholds, thresholds...)
+	return scenario
+}
+
+// WithCorrelation sets the correlation configuration for the contexts of the scenario.
+func (scenario *TestScenario) WithCorrelation(config *CorrelationConfig) *TestScenario {
+	scenario.Correlation = config
+	return scenario
+}
+
+// DependsOn adds the names of scenarios, which RunTestScenarios runs before this one,
+// also if they do not match the filter criteria. If one of them fails, this scenario is skipped.
+func (scenario *TestScenario) DependsOn(names ...string) *TestScenario {
+	scenario.Dependencies = append(scenario.Dependencies, names...)
+	return scenario
+}
+
+// WithBeforeAll adds hooks, which run once before the first execution of the scenario.
+func (scenario *TestScenario) WithBeforeAll(hooks ...SetupHook) *TestScenario {
+	scenario.Hooks.BeforeAll = append(scenario.Hooks.BeforeAll, hooks...)
+	return scenario
+}
+
+// WithAfterAll adds hooks, which run once after the last execution of the scenario.
+func (scenario *TestScenario) WithAfterAll(hooks ...TeardownHook) *TestScenario {
+	scenario.Hooks.AfterAll = append(scenario.Hooks.AfterAll, hooks...)
+	return scenario
+}
+
+// WithBeforeEach adds hooks, which run before each execution of the scenario.
+func (scenario *TestScenario) WithBeforeEach(hooks ...SetupHook) *TestScenario {
+	scenario.Hooks.BeforeEach = append(scenario.Hooks.BeforeEach, hooks...)
+	return scenario
+}
+
+// WithAfterEach adds hooks, which run after each execution of the scenario.
+func (scenario *TestScenario) WithAfterEach(hooks ...TeardownHook) *TestScenario {
+	scenario.Hooks.AfterEach = append(scenario.Hooks.AfterEach, hooks...)
+	return scenario
+}
@@ -0,0 +1,244 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics, which can be used in thresholds.
+// Additionally, percentiles can be used as p<N>, e.g. p95 or p99.9.
+const (
+	MetricMean       = "mean"
+	MetricMin        = "min"
+	MetricMax        = "max"
+	MetricErrorRate  = "error_rate"
+	MetricThroughput = "throughput"
+	MetricCount      = "count"
+)
+
+// Threshold is a pass/fail criterion on the aggregated results of a scenario.
+type Threshold struct {
+	// Metric is one of the Metric* constants or a percentile like p95.
+	Metric string
+	// Step is the name of the step, or empty for the scenario total.
+	Step string
+	// Operator is one of <, <=, >, >=.
+	Operator string
+	// Limit is a duration in nanoseconds for latency metrics,
+	// a fraction between 0 and 1 for the error rate
+	// and executions per second for the throughput.
+	Limit float64
+	// AbortAfter enables the evaluation during the run, starting after this duration.
+	// If the threshold fails during the run, the scenario is aborted.
+	AbortAfter time.Duration
+}
+
+// ThresholdResult is the evaluation of a threshold.
+type ThresholdResult struct {
+	Threshold *Threshold
+	Observed  float64
+	// NoData is true, if there were no executions of the step.
+	NoData bool
+	Passed bool
+}
+
+// Verdict is the evaluation of all thresholds of a scenario.
+type Verdict struct {
+	Results []ThresholdResult
+}
+
+var thresholdRegex = regexp.MustCompile(`^\s*([a-z_]+|p[0-9.]+)\s*(?:\((.*)\))?\s*(<=|>=|<|>)\s*(\S+(?:\s+rps)?)\s*$`)
+
+// ParseThreshold parses a threshold expression of the form
+// <metric>[(<step>)] <operator> <limit>, e.g.
+//
+//	p95(login) < 300ms
+//	error_rate < 1%
+//	throughput > 200 rps
+//
+// The unit rps of the throughput is optional.
+func ParseThreshold(expression string) (*Threshold, error) {
+	match := thresholdRegex.FindStringSubmatch(expression)
+	if match == nil {
+		return nil, fmt.Errorf("invalid threshold %q, expected: <metric>[(<step>)] <operator> <limit>", expression)
+	}
+	threshold := &Threshold{
+		Metric:   match[1],
+		Step:     match[2],
+		Operator: match[3],
+	}
+	limit := match[4]
+	var err error
+	switch {
+	case threshold.isLatency():
+		var d time.Duration
+		d, err = time.ParseDuration(limit)
+		threshold.Limit = float64(d)
+	case threshold.Metric == MetricErrorRate && strings.HasSuffix(limit, "%"):
+		threshold.Limit, err = strconv.ParseFloat(strings.TrimSuffix(limit, "%"), 64)
+		threshold.Limit /= 100
+	case threshold.Metric == MetricThroughput:
+		threshold.Limit, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(limit, "rps")), 64)
+	case threshold.Metric == MetricErrorRate || threshold.Metric == MetricCount:
+		threshold.Limit, err = strconv.ParseFloat(limit, 64)
+	default:
+		return nil, fmt.Errorf("invalid threshold %q, unknown metric %q", expression, threshold.Metric)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold %q: %v", expression, err)
+	}
+	return threshold, nil
+}
+
+// MustThreshold is like ParseThreshold, but panics if the expression is invalid.
+func MustThreshold(expression string) *Threshold {
+	threshold, err := ParseThreshold(expression)
+	if err != nil {
+		panic(err)
+	}
+	return threshold
+}
+
+// AbortOnFail enables the evaluation during the run, after the supplied duration.
+func (threshold *Threshold) AbortOnFail(after time.Duration) *Threshold {
+	threshold.AbortAfter = after
+	return threshold
+}
+
+func (threshold *Threshold) isLatency() bool {
+	return threshold.percentile() > 0 ||
+		threshold.Metric == MetricMean || threshold.Metric == MetricMin || threshold.Metric == MetricMax
+}
+
+// percentile returns the percentile of a p<N> metric, or 0 for other metrics.
+func (threshold *Threshold) percentile() float64 {
+	if !strings.HasPrefix(threshold.Metric, "p") {
+		return 0
+	}
+	p, err := strconv.ParseFloat(threshold.Metric[1:], 64)
+	if err != nil || p <= 0 || p > 100 {
+		return 0
+	}
+	return p
+}
+
+// Evaluate checks the threshold against the stats.
+func (threshold *Threshold) Evaluate(stats *Stats) ThresholdResult {
+	summary := stats.Total()
+	if threshold.Step != "" {
+		var exists bool
+		if summary, exists = stats.Step(threshold.Step); !exists {
+			return ThresholdResult{Threshold: threshold, NoData: true}
+		}
+	}
+	if summary.Count == 0 {
+		return ThresholdResult{Threshold: threshold, NoData: true}
+	}
+
+	var observed float64
+	switch threshold.Metric {
+	case MetricMean:
+		observed = float64(summary.Mean)
+	case MetricMin:
+		observed = float64(summary.Min)
+	case MetricMax:
+		observed = float64(summary.Max)
+	case MetricErrorRate:
+		observed = summary.ErrorRate()
+	case MetricThroughput:
+		observed = summary.Throughput()
+	case MetricCount:
+		observed = float64(summary.Count)
+	default:
+		observed = float64(stats.Percentile(threshold.Step, threshold.percentile()))
+	}
+
+	return ThresholdResult{
+		Threshold: threshold,
+		Observed:  observed,
+		Passed:    threshold.compare(observed),
+	}
+}
+
+func (threshold *Threshold) compare(observed float64) bool {
+	switch threshold.Operator {
+	case "<":
+		return observed < threshold.Limit
+	case "<=":
+		return observed <= threshold.Limit
+	case ">":
+		return observed > threshold.Limit
+	case ">=":
+		return observed >= threshold.Limit
+	}
+	return false
+}
+
+// formatValue formats the value in the unit of the metric.
+// A precision of -1 uses the minimal number of digits.
+func (threshold *Threshold) formatValue(value float64, precision int) string {
+	switch {
+	case threshold.isLatency():
+		return formatDuration(time.Duration(value))
+	case threshold.Metric == MetricErrorRate:
+		return strconv.FormatFloat(value*100, 'f', precision, 64) + "%"
+	default:
+		return strconv.FormatFloat(value, 'f', precision, 64)
+	}
+}
+
+func (threshold *Threshold) String() string {
+	metric := threshold.Metric
+	if threshold.Step != "" {
+		metric += "(" + threshold.Step + ")"
+	}
+	return fmt.Sprintf("%v %v %v", metric, threshold.Operator, threshold.formatValue(threshold.Limit, -1))
+}
+
+func (result ThresholdResult) String() string {
+	status := "passed"
+	if !result.Passed {
+		status = "failed"
+	}
+	if result.NoData {
+		return fmt.Sprintf("%v: no data, %v", result.Threshold, status)
+	}
+	return fmt.Sprintf("%v: observed %v, %v", result.Threshold, result.Threshold.formatValue(result.Observed, 2), status)
+}
+
+// EvaluateThresholds evaluates all thresholds against the stats.
+func EvaluateThresholds(stats *Stats, thresholds []*Threshold) *Verdict {
+	verdict := &Verdict{Results: make([]ThresholdResult, 0, len(thresholds))}
+	for _, threshold := range thresholds {
+		verdict.Results = append(verdict.Results, threshold.Evaluate(stats))
+	}
+	return verdict
+}
+
+// Passed returns true, if all thresholds passed.
+func (verdict *Verdict) Passed() bool {
+	return len(verdict.Failed()) == 0
+}
+
+// Failed returns the results of the failed thresholds.
+func (verdict *Verdict) Failed() []ThresholdResult {
+	failed := []ThresholdResult{}
+	for _, result := range verdict.Results {
+		if !result.Passed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+func (verdict *Verdict) String() string {
+	b := bytes.NewBuffer(nil)
+	for _, result := range verdict.Results {
+		fmt.Fprintln(b, result.String())
+	}
+	return b.String()
+}
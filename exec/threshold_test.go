@@ -0,0 +1,79 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_ParseThreshold(t *testing.T) {
+	a := assert.New(t)
+
+	threshold, err := ParseThreshold("p95(login) < 300ms")
+	a.NoError(err)
+	a.Equal(&Threshold{Metric: "p95", Step: "login", Operator: "<", Limit: float64(300 * time.Millisecond)}, threshold)
+	a.Equal("p95(login) < 300ms", threshold.String())
+
+	threshold, err = ParseThreshold("error_rate<1%")
+	a.NoError(err)
+	a.Equal(&Threshold{Metric: MetricErrorRate, Operator: "<", Limit: 0.01}, threshold)
+	a.Equal("error_rate < 1%", threshold.String())
+
+	threshold, err = ParseThreshold(" throughput(->GET /api (v2)) >= 200.5 ")
+	a.NoError(err)
+	a.Equal(&Threshold{Metric: MetricThroughput, Step: "->GET /api (v2)", Operator: ">=", Limit: 200.5}, threshold)
+
+	for _, expression := range []string{"throughput > 200 rps", "throughput>200rps", "throughput > 200"} {
+		threshold, err = ParseThreshold(expression)
+		a.NoError(err, expression)
+		a.Equal(&Threshold{Metric: MetricThroughput, Operator: ">", Limit: 200}, threshold)
+	}
+
+	for _, invalid := range []string{"", "p95 < 300", "foo < 3", "p95 = 3ms", "error_rate < x%", "p95(login) <", "count > 5 rps", "throughput > rps"} {
+		_, err = ParseThreshold(invalid)
+		a.Error(err, invalid)
+	}
+	a.Panics(func() { MustThreshold("invalid") })
+}
+
+func Test_Threshold_Evaluate(t *testing.T) {
+	a := assert.New(t)
+	start := time.Now()
+
+	stats := NewStats()
+	for i := 1; i <= 100; i++ {
+		var err error
+		if i%50 == 0 {
+			err = errors.New("failed")
+		}
+		stats.Add(newTestExecution("scenario", start.Add(time.Duration(i)*10*time.Millisecond), time.Duration(i)*time.Millisecond, err,
+			newTestExecution("login", start, time.Duration(i)*time.Millisecond, nil)))
+	}
+
+	verdict := EvaluateThresholds(stats, []*Threshold{
+		MustThreshold("p95(login) < 300ms"),
+		MustThreshold("p95(login) < 90ms"),
+		MustThreshold("error_rate < 1%"),
+		MustThreshold("error_rate <= 2%"),
+		MustThreshold("throughput > 50"),
+		MustThreshold("max <= 100ms"),
+		MustThreshold("count >= 100"),
+		MustThreshold("mean(unknown) < 1s"),
+	})
+
+	a.False(verdict.Passed())
+	passed := []bool{}
+	for _, result := range verdict.Results {
+		passed = append(passed, result.Passed)
+	}
+	a.Equal([]bool{true, false, false, true, true, true, true, false}, passed)
+	a.Equal(float64(95*time.Millisecond), verdict.Results[0].Observed)
+	a.Equal(0.02, verdict.Results[2].Observed)
+	a.True(verdict.Results[7].NoData)
+	a.Equal(3, len(verdict.Failed()))
+
+	a.Equal("p95(login) < 90ms: observed 95ms, failed", verdict.Results[1].String())
+	a.Equal("error_rate < 1%: observed 2.00%, failed", verdict.Results[2].String())
+	a.Equal("mean(unknown) < 1s: no data, failed", verdict.Results[7].String())
+}